@@ -0,0 +1,331 @@
+package eek
+
+import (
+	"fmt"
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"path"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const (
+	// generatedFileName is the file name of positions of the generated code that follows a type checked source
+	generatedFileName = "eek-generated"
+)
+
+var (
+	regexMajorVersion = regexp.MustCompile(`^v[0-9]+$`)
+)
+
+// Analysis is the result of the static analysis of the evaluation formula and the defined functions
+type Analysis struct {
+	UndefinedIdentifiers []Identifier
+	UnusedVariables      []string
+	UnusedFunctions      []string
+	Metrics              Metrics
+	Limits               Limits
+}
+
+// Identifier is reflect to a single identifier found in the evaluation formula or in a defined function
+type Identifier struct {
+	Name string
+	Pos  token.Position
+}
+
+// source is a parsed piece of user written code, either the evaluation formula or a function body
+type source struct {
+	name      string
+	code      string
+	prefixLen int // length of the wrapper code placed before the user written code
+	isFormula bool
+	fset      *token.FileSet
+	file      *ast.File
+	node      ast.Node
+}
+
+// offset returns the position of pos relative to the beginning of the user written code
+func (s *source) offset(pos token.Pos) int {
+	return s.fset.Position(pos).Offset - s.prefixLen
+}
+
+func parseFormula(name, code string) (*source, error) {
+	prefix := fmt.Sprintf("package main\nfunc _() {\n//line %s:1:1\n", name)
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", prefix+code+"\n}\n", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// code that closes the wrapper body would declare things outside of the formula
+	if len(file.Decls) != 1 {
+		return nil, fmt.Errorf("%s: formula must be a function body, declarations outside of it are not allowed", name)
+	}
+
+	node := file.Decls[0].(*ast.FuncDecl).Body
+	return &source{name, code, len(prefix), true, fset, file, node}, nil
+}
+
+func parseFunction(name, code string) (*source, error) {
+	prefix := fmt.Sprintf("package main\nvar _ =\n//line %s:1:1\n", name)
+
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "", prefix+code+"\n", 0)
+	if err != nil {
+		return nil, err
+	}
+
+	genDecl, ok := file.Decls[0].(*ast.GenDecl)
+	if !ok || len(file.Decls) != 1 || len(genDecl.Specs) != 1 || len(genDecl.Specs[0].(*ast.ValueSpec).Values) != 1 {
+		return nil, fmt.Errorf("%s: function body must be a single function literal", name)
+	}
+
+	node := genDecl.Specs[0].(*ast.ValueSpec).Values[0]
+	return &source{name, code, len(prefix), false, fset, file, node}, nil
+}
+
+// checkedSources is reflect to the formulas and the defined functions type checked along with the generated code
+type checkedSources struct {
+	fset  *token.FileSet
+	files []*ast.File
+	info  *types.Info
+	names map[string]bool // names of the formulas and the defined functions
+}
+
+// inspect call fn for every node of the formulas and the defined functions, nodes of the generated code are skipped
+func (c *checkedSources) inspect(fn func(node ast.Node)) {
+	for _, file := range c.files {
+		ast.Inspect(file, func(node ast.Node) bool {
+			if node != nil && c.names[c.fset.Position(node.Pos()).Filename] {
+				fn(node)
+			}
+			return true
+		})
+	}
+}
+
+// typeCheckSources type check the formulas and the defined functions along with the generated code. Type errors are ignored, expressions that are not type checked have no type information
+func (e *Eek) typeCheckSources() (*checkedSources, error) {
+	checked := &checkedSources{fset: token.NewFileSet(), names: make(map[string]bool)}
+
+	// formulas and function bodies are annotated by line directive, so the positions match the ones of the sources
+	annotate := func(name, code string) string {
+		checked.names[name] = true
+		return fmt.Sprintf("\n//line %s:1:1\n%s\n//line %s:1\n", name, code, generatedFileName)
+	}
+	annotated := &rewrittenSources{formulas: make(map[string]string), functions: make(map[string]string)}
+	for _, each := range e.entries {
+		annotated.formulas[each.name] = annotate(each.name, each.formula)
+	}
+	for _, each := range e.functions {
+		annotated.functions[each.Name] = annotate(each.Name, strings.TrimSpace(each.BodyFunction))
+	}
+
+	for _, each := range [][2]string{{"main.go", e.layoutSimpleEvaluation(annotated)}, {"support.go", e.supportFile()}} {
+		if each[1] == "" {
+			continue
+		}
+
+		file, err := parser.ParseFile(checked.fset, each[0], each[1], 0)
+		if err != nil {
+			return nil, err
+		}
+		checked.files = append(checked.files, file)
+	}
+
+	checked.info = &types.Info{
+		Types:      make(map[ast.Expr]types.TypeAndValue),
+		Selections: make(map[*ast.SelectorExpr]*types.Selection),
+		Uses:       make(map[*ast.Ident]types.Object),
+	}
+	config := types.Config{Importer: importer.Default(), Error: func(error) {}}
+	config.Check("main", checked.fset, checked.files, checked.info)
+
+	return checked, nil
+}
+
+// parseSources parse every evaluation formula and every defined function
+func (e *Eek) parseSources() ([]*source, error) {
+	sources := make([]*source, 0)
+
+	for _, entry := range e.entries {
+		each, err := parseFormula(entry.name, entry.formula)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, each)
+	}
+
+	for _, fun := range e.functions {
+		bodyFunc := strings.TrimSpace(fun.BodyFunction)
+		if fun.Name == "" || bodyFunc == "" {
+			continue
+		}
+
+		each, err := parseFunction(fun.Name, bodyFunc)
+		if err != nil {
+			return nil, err
+		}
+		sources = append(sources, each)
+	}
+
+	return sources, nil
+}
+
+// declaredIdentifiers returns every identifier accessible from the evaluation formula, except the go predeclared identifiers
+func (e *Eek) declaredIdentifiers() map[string]bool {
+	declared := make(map[string]bool)
+
+	for _, each := range e.packages {
+		if each != "" {
+			declared[packageName(each)] = true
+		}
+	}
+	for _, each := range e.types {
+		declared[each.Name] = true
+	}
+	for _, each := range e.constants {
+		declared[each.Name] = true
+	}
+	for _, each := range e.functions {
+		declared[each.Name] = true
+	}
+	for _, each := range e.variables {
+		declared[each.Name] = true
+	}
+	for _, each := range e.outputs {
+		declared[each.Name] = true
+	}
+	for _, each := range e.entries {
+		declared[each.name] = true
+	}
+	for _, each := range e.hostFunctions {
+		declared[each.name] = true
+	}
+	if len(e.optionalVariables()) > 0 {
+		declared[isSetFunction] = true
+	}
+	if e.UseEmit {
+		declared[emitFunction] = true
+	}
+
+	return declared
+}
+
+// Analyze parse the evaluation formula and the defined functions, then report undefined identifiers, unused variables, unused functions, and the complexity metrics
+func (e *Eek) Analyze() (*Analysis, error) {
+	sources, err := e.parseSources()
+	if err != nil {
+		return nil, err
+	}
+
+	declared := e.declaredIdentifiers()
+	used := make(map[string]bool)
+	analysis := new(Analysis)
+	analysis.UndefinedIdentifiers = make([]Identifier, 0)
+	analysis.UnusedVariables = make([]string, 0)
+	analysis.UnusedFunctions = make([]string, 0)
+
+	for _, each := range sources {
+		for _, ident := range each.file.Unresolved {
+			if !each.isFormula && ident.Name == each.name {
+				continue
+			}
+
+			if declared[ident.Name] {
+				used[ident.Name] = true
+			} else if types.Universe.Lookup(ident.Name) == nil {
+				analysis.UndefinedIdentifiers = append(analysis.UndefinedIdentifiers, Identifier{
+					Name: ident.Name,
+					Pos:  each.fset.Position(ident.Pos()),
+				})
+			}
+		}
+	}
+
+	for _, each := range e.variables {
+		if each.Name != "" && !used[each.Name] {
+			analysis.UnusedVariables = append(analysis.UnusedVariables, each.Name)
+		}
+	}
+	for _, each := range e.functions {
+		if each.Name != "" && !used[each.Name] {
+			analysis.UnusedFunctions = append(analysis.UnusedFunctions, each.Name)
+		}
+	}
+
+	analysis.Metrics = e.measure(sources)
+	analysis.Limits = e.limits
+
+	return analysis, nil
+}
+
+// AutoDeclareVariables enable the auto declaration of variables. On build, every undefined identifier of the evaluation formula that has a value in the sample will be declared as variable, typed after the value
+func (e *Eek) AutoDeclareVariables(sample ExecVar) {
+	e.variableSample = sample
+}
+
+func (e *Eek) declareMissingVariables() error {
+	analysis, err := e.Analyze()
+	if err != nil {
+		return err
+	}
+
+	names := make([]string, 0)
+	for _, each := range analysis.UndefinedIdentifiers {
+		if _, ok := e.variableSample[each.Name]; ok {
+			names = appendUnique(names, each.Name)
+		}
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		value := e.variableSample[name]
+		if value == nil {
+			return fmt.Errorf("cannot infer type of variable %s from a nil value", name)
+		}
+
+		typ, imports, err := typeExpr(reflect.TypeOf(value))
+		if err != nil {
+			return fmt.Errorf("cannot infer type of variable %s. %s", name, err.Error())
+		}
+
+		for _, each := range imports {
+			if !e.isPackageImported(each) {
+				e.ImportPackage(each)
+			}
+		}
+		e.DefineVariable(Var{Name: name, Type: typ})
+	}
+
+	return nil
+}
+
+func (e *Eek) isPackageImported(pkg string) bool {
+	for _, each := range e.packages {
+		if each == pkg {
+			return true
+		}
+	}
+
+	return false
+}
+
+// packageName guess the name of a package from its import path
+func packageName(importPath string) string {
+	name := path.Base(importPath)
+	if regexMajorVersion.MatchString(name) && path.Dir(importPath) != "." {
+		name = path.Base(path.Dir(importPath))
+	}
+
+	name = strings.TrimPrefix(name, "go-")
+	name = strings.TrimSuffix(name, "-go")
+	name = strings.TrimSuffix(name, ".go")
+	return regexFileName.ReplaceAllString(name, "_")
+}
@@ -0,0 +1,69 @@
+package eek
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAnalyze(t *testing.T) {
+	Convey("Analyze evaluation formula", t, func() {
+		obj := New("analysis")
+		obj.ImportPackage("strings")
+		obj.DefineVariable(Var{Name: "A", Type: "int"})
+		obj.DefineVariable(Var{Name: "Unused", Type: "string"})
+		obj.DefineFunction(Func{Name: "Double", BodyFunction: `func(n int) int { return n * 2 }`})
+		obj.DefineFunction(Func{Name: "Never", BodyFunction: `func() int { return Never() }`})
+		obj.PrepareEvaluation(`
+			text := strings.Repeat("x", Double(A))
+			return len(text) + Typo
+		`)
+
+		analysis, err := obj.Analyze()
+		So(err, ShouldBeNil)
+		So(len(analysis.UndefinedIdentifiers), ShouldEqual, 1)
+		So(analysis.UndefinedIdentifiers[0].Name, ShouldEqual, "Typo")
+		So(analysis.UndefinedIdentifiers[0].Pos.String(), ShouldEqual, "Evaluate:2:23")
+		So(analysis.UnusedVariables, ShouldResemble, []string{"Unused"})
+		So(analysis.UnusedFunctions, ShouldResemble, []string{"Never"})
+	})
+
+	Convey("Analyze invalid evaluation formula", t, func() {
+		obj := New("analysis")
+		obj.PrepareEvaluation(`return 1 +`)
+
+		_, err := obj.Analyze()
+		So(err, ShouldBeError)
+	})
+}
+
+func TestAutoDeclareVariables(t *testing.T) {
+	Convey("Auto declare variables from sample", t, func() {
+		obj := New("auto declare variables")
+		obj.DefineVariable(Var{Name: "A", Type: "int"})
+		obj.AutoDeclareVariables(ExecVar{"B": 2.5, "Names": []string{"x"}, "Ignored": true})
+		obj.PrepareEvaluation(`return float64(A+len(Names)) * B`)
+
+		err := obj.Build()
+		So(err, ShouldBeNil)
+		So(obj.variables, ShouldResemble, []Var{
+			{Name: "A", Type: "int"},
+			{Name: "B", Type: "float64"},
+			{Name: "Names", Type: "[]string"},
+		})
+
+		output, err := obj.Evaluate(ExecVar{"A": 1, "B": 1.5, "Names": []string{"x", "y"}})
+		So(err, ShouldBeNil)
+		So(output, ShouldEqual, 4.5)
+	})
+
+	Convey("Auto declare variables with unsupported sample", t, func() {
+		obj := New("auto declare variables")
+		obj.AutoDeclareVariables(ExecVar{"A": nil})
+		obj.PrepareEvaluation(`return A`)
+
+		err := obj.Build()
+		So(err, ShouldBeError)
+		So(err.Error(), ShouldEqual, "cannot infer type of variable A from a nil value")
+	})
+}
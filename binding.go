@@ -0,0 +1,127 @@
+package eek
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// UnknownVariablePolicy define how the evaluation handle ExecVar keys that are not declared as variable
+type UnknownVariablePolicy int
+
+const (
+	// UnknownVariableReject fail the evaluation when there is unknown key
+	UnknownVariableReject UnknownVariablePolicy = iota
+
+	// UnknownVariableIgnore skip unknown keys silently
+	UnknownVariableIgnore
+
+	// UnknownVariableCollect skip unknown keys and continue the evaluation. The result is returned along with an *UnknownVariableError that report the skipped keys
+	UnknownVariableCollect
+)
+
+// UnknownVariableError is returned when ExecVar has keys that are not declared as variable
+type UnknownVariableError struct {
+	Names []string
+
+	// Suggestions holds the closest declared variable name of each unknown name, if any
+	Suggestions map[string]string
+}
+
+func (e *UnknownVariableError) Error() string {
+	messages := make([]string, 0)
+	for _, name := range e.Names {
+		message := fmt.Sprintf("variable %s is not declared", name)
+		if suggestion, ok := e.Suggestions[name]; ok {
+			message = fmt.Sprintf("%s, did you mean %s?", message, suggestion)
+		}
+		messages = append(messages, message)
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+func (e *Eek) isVariableDeclared(name string) bool {
+	for _, each := range e.variables {
+		if each.Name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// unknownVariables returns an error that report keys of data that are not declared as variable, or nil when there is none
+func (e *Eek) unknownVariables(data ExecVar) *UnknownVariableError {
+	names := make([]string, 0)
+	for name := range data {
+		if !e.isVariableDeclared(name) {
+			names = append(names, name)
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	sort.Strings(names)
+
+	suggestions := make(map[string]string)
+	for _, name := range names {
+		if suggestion := e.closestVariableName(name); suggestion != "" {
+			suggestions[name] = suggestion
+		}
+	}
+
+	return &UnknownVariableError{Names: names, Suggestions: suggestions}
+}
+
+// closestVariableName returns the declared variable name that is most similar to name, or an empty string when nothing is close enough
+func (e *Eek) closestVariableName(name string) string {
+	closest := ""
+	closestDistance := len(name)/3 + 2
+
+	for _, each := range e.variables {
+		distance := levenshtein(strings.ToLower(name), strings.ToLower(each.Name))
+		if distance < closestDistance {
+			closest = each.Name
+			closestDistance = distance
+		}
+	}
+
+	return closest
+}
+
+func levenshtein(a, b string) int {
+	source, target := []rune(a), []rune(b)
+	previous := make([]int, len(target)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(source); i++ {
+		current := make([]int, len(target)+1)
+		current[0] = i
+
+		for j := 1; j <= len(target); j++ {
+			cost := 1
+			if source[i-1] == target[j-1] {
+				cost = 0
+			}
+
+			current[j] = min3(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous = current
+	}
+
+	return previous[len(target)]
+}
+
+func min3(a, b, c int) int {
+	if b < a {
+		a = b
+	}
+	if c < a {
+		a = c
+	}
+
+	return a
+}
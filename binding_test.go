@@ -0,0 +1,48 @@
+package eek
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestUnknownVariable(t *testing.T) {
+	Convey("Create Eek object with declared variables", t, func() {
+		obj := New("unknown variables")
+		obj.DefineVariable(Var{Name: "Amount", Type: "int"})
+		obj.DefineFunction(Func{Name: "IF", BodyFunction: `func(cond bool, ok, nok int) int { if cond { return ok }; return nok }`})
+		obj.SetReturnType("int")
+		obj.PrepareEvaluation(`return IF(Amount > 10, 1, 0)`)
+
+		Convey("Build operation", func() {
+			err := obj.Build()
+			So(err, ShouldBeNil)
+
+			Convey("Test reject unknown variables", func() {
+				_, err := obj.Evaluate(ExecVar{"Amount": 20, "amuont": 1, "IF": nil})
+				So(err, ShouldBeError)
+				So(err.Error(), ShouldEqual, "variable IF is not declared; variable amuont is not declared, did you mean Amount?")
+
+				unknownErr, ok := err.(*UnknownVariableError)
+				So(ok, ShouldBeTrue)
+				So(unknownErr.Names, ShouldResemble, []string{"IF", "amuont"})
+				So(unknownErr.Suggestions, ShouldResemble, map[string]string{"amuont": "Amount"})
+			})
+
+			Convey("Test ignore unknown variables", func() {
+				obj.UnknownVariablePolicy = UnknownVariableIgnore
+				output, err := obj.EvaluateInt(ExecVar{"Amount": 20, "IF": nil})
+				So(err, ShouldBeNil)
+				So(output, ShouldEqual, 1)
+			})
+
+			Convey("Test collect unknown variables", func() {
+				obj.UnknownVariablePolicy = UnknownVariableCollect
+				output, err := obj.EvaluateInt(ExecVar{"Amount": 5, "Amont": 20})
+				So(output, ShouldEqual, 0)
+				So(err, ShouldBeError)
+				So(err.Error(), ShouldEqual, "variable Amont is not declared, did you mean Amount?")
+			})
+		})
+	})
+}
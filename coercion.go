@@ -0,0 +1,281 @@
+package eek
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"plugin"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+// Coercion define which conversions are applied when an ExecVar value is not assignable to the type of its variable. Values can be combined, e.g. CoerceNumericWidening | CoerceJSONNumber
+type Coercion int
+
+const (
+	// CoerceNumericWidening convert numbers into a type that is able to hold every value of the original type, e.g. int32 into int64. Integers into floats are included, as long as the value is exactly representable
+	CoerceNumericWidening Coercion = 1 << iota
+
+	// CoerceNumericNarrowing convert numbers into a smaller type, e.g. int64 into int8 or float64 into int, as long as the value is not changed
+	CoerceNumericNarrowing
+
+	// CoerceString parse strings into number, bool, time.Duration, and time.Time (RFC 3339)
+	CoerceString
+
+	// CoerceJSONNumber convert json.Number into number or string
+	CoerceJSONNumber
+
+	// CoerceSlice convert slices element by element, e.g. []interface{} into []float64
+	CoerceSlice
+
+	// CoerceStrict does not convert anything, the value must be assignable to the variable type
+	CoerceStrict Coercion = 0
+
+	// CoerceAll apply every conversion
+	CoerceAll = CoerceNumericWidening | CoerceNumericNarrowing | CoerceString | CoerceJSONNumber | CoerceSlice
+)
+
+var (
+	typeTime     = reflect.TypeOf(time.Time{})
+	typeDuration = reflect.TypeOf(time.Duration(0))
+	typeNumber   = reflect.TypeOf(json.Number(""))
+)
+
+// coerceVariables convert values of declared variables into the type of their variable, following the coercion policy
+func (e *Eek) coerceVariables(p *plugin.Plugin, data ExecVar) (ExecVar, error) {
+	if e.Coercion == CoerceStrict {
+		return data, nil
+	}
+
+	coerced := make(ExecVar)
+	for varName, varValue := range data {
+		coerced[varName] = varValue
+		if !e.isVariableDeclared(varName) || varValue == nil {
+			continue
+		}
+
+		lookedUpVar, err := p.Lookup(varName)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := coerce(reflect.ValueOf(varValue), reflect.TypeOf(lookedUpVar).Elem(), e.Coercion)
+		if err != nil {
+			return nil, fmt.Errorf("Error on coercing value %v (type %T) of variable %s. %s", varValue, varValue, varName, err.Error())
+		}
+		coerced[varName] = value.Interface()
+	}
+
+	return coerced, nil
+}
+
+// coerce convert value into the target type. Value is returned as it is when there is no applicable conversion
+func coerce(value reflect.Value, target reflect.Type, policy Coercion) (reflect.Value, error) {
+	if value.Type().AssignableTo(target) {
+		return value, nil
+	}
+
+	// unwrap interface{} values, such as elements of []interface{}
+	if value.Kind() == reflect.Interface && !value.IsNil() {
+		return coerce(value.Elem(), target, policy)
+	}
+
+	switch {
+	case value.Type() == typeNumber && policy&CoerceJSONNumber != 0:
+		return coerceString(value, target, true)
+	case value.Kind() == reflect.String && policy&CoerceString != 0:
+		return coerceString(value, target, false)
+	case isNumberKind(value.Kind()) && isNumberKind(target.Kind()):
+		if isWideningConversion(value.Type(), target) {
+			if policy&CoerceNumericWidening == 0 {
+				return value, nil
+			}
+		} else if policy&CoerceNumericNarrowing == 0 {
+			return value, nil
+		}
+		return coerceNumber(value, target)
+	case value.Kind() == reflect.Slice && target.Kind() == reflect.Slice && policy&CoerceSlice != 0:
+		result := reflect.MakeSlice(target, value.Len(), value.Len())
+		for i := 0; i < value.Len(); i++ {
+			elem, err := coerce(value.Index(i), target.Elem(), policy)
+			if err != nil {
+				return value, fmt.Errorf("index %d: %s", i, err.Error())
+			} else if !elem.Type().AssignableTo(target.Elem()) {
+				return value, fmt.Errorf("index %d: value %v (type %s) is not assignable to type %s", i, elem.Interface(), elem.Type(), target.Elem())
+			}
+			result.Index(i).Set(elem)
+		}
+		return result, nil
+	}
+
+	return value, nil
+}
+
+func coerceString(value reflect.Value, target reflect.Type, isNumber bool) (reflect.Value, error) {
+	str := value.String()
+	result := reflect.New(target).Elem()
+
+	switch {
+	case target == typeDuration && !isNumber:
+		duration, err := time.ParseDuration(str)
+		if err != nil {
+			return result, err
+		}
+		result.SetInt(int64(duration))
+	case target == typeTime && !isNumber:
+		t, err := time.Parse(time.RFC3339, str)
+		if err != nil {
+			return result, err
+		}
+		result.Set(reflect.ValueOf(t))
+	case isIntKind(target.Kind()):
+		number, err := strconv.ParseInt(str, 10, target.Bits())
+		if err != nil {
+			return result, err
+		}
+		result.SetInt(number)
+	case isUintKind(target.Kind()):
+		number, err := strconv.ParseUint(str, 10, target.Bits())
+		if err != nil {
+			return result, err
+		}
+		result.SetUint(number)
+	case target.Kind() == reflect.Float32 || target.Kind() == reflect.Float64:
+		number, err := strconv.ParseFloat(str, target.Bits())
+		if err != nil {
+			return result, err
+		}
+		result.SetFloat(number)
+	case target.Kind() == reflect.Bool && !isNumber:
+		b, err := strconv.ParseBool(str)
+		if err != nil {
+			return result, err
+		}
+		result.SetBool(b)
+	case target.Kind() == reflect.String:
+		result.SetString(str)
+	default:
+		return value, nil
+	}
+
+	return result, nil
+}
+
+// coerceNumber convert number into the target type, it fails when the value is changed by the conversion
+func coerceNumber(value reflect.Value, target reflect.Type) (reflect.Value, error) {
+	result := reflect.New(target).Elem()
+	lossError := fmt.Errorf("value %v cannot be represented by type %s", value.Interface(), target)
+
+	switch {
+	case isIntKind(value.Kind()):
+		number := value.Int()
+		switch {
+		case isIntKind(target.Kind()):
+			if result.OverflowInt(number) {
+				return value, lossError
+			}
+			result.SetInt(number)
+		case isUintKind(target.Kind()):
+			if number < 0 || result.OverflowUint(uint64(number)) {
+				return value, lossError
+			}
+			result.SetUint(uint64(number))
+		default:
+			if !isExactFloat(float64(number), target) || int64(float64(number)) != number {
+				return value, lossError
+			}
+			result.SetFloat(float64(number))
+		}
+	case isUintKind(value.Kind()):
+		number := value.Uint()
+		switch {
+		case isIntKind(target.Kind()):
+			if number > math.MaxInt64 || result.OverflowInt(int64(number)) {
+				return value, lossError
+			}
+			result.SetInt(int64(number))
+		case isUintKind(target.Kind()):
+			if result.OverflowUint(number) {
+				return value, lossError
+			}
+			result.SetUint(number)
+		default:
+			if !isExactFloat(float64(number), target) || uint64(float64(number)) != number {
+				return value, lossError
+			}
+			result.SetFloat(float64(number))
+		}
+	default:
+		number := value.Float()
+		switch {
+		case isIntKind(target.Kind()):
+			if number != math.Trunc(number) || number < math.MinInt64 || number >= math.MaxInt64 || result.OverflowInt(int64(number)) {
+				return value, lossError
+			}
+			result.SetInt(int64(number))
+		case isUintKind(target.Kind()):
+			if number != math.Trunc(number) || number < 0 || number >= math.MaxUint64 || result.OverflowUint(uint64(number)) {
+				return value, lossError
+			}
+			result.SetUint(uint64(number))
+		default:
+			if !isExactFloat(number, target) {
+				return value, lossError
+			}
+			result.SetFloat(number)
+		}
+	}
+
+	return result, nil
+}
+
+// isWideningConversion returns true when the target type is able to hold every value of the source type. Integer into float is counted as widening, the value is checked on conversion
+func isWideningConversion(source, target reflect.Type) bool {
+	switch {
+	case isIntKind(source.Kind()) && isIntKind(target.Kind()):
+		return target.Bits() >= source.Bits()
+	case isUintKind(source.Kind()) && isUintKind(target.Kind()):
+		return target.Bits() >= source.Bits()
+	case isUintKind(source.Kind()) && isIntKind(target.Kind()):
+		return target.Bits() > source.Bits()
+	case isFloatKind(source.Kind()):
+		return isFloatKind(target.Kind()) && target.Bits() >= source.Bits()
+	}
+
+	return isFloatKind(target.Kind())
+}
+
+func isExactFloat(number float64, target reflect.Type) bool {
+	if target.Kind() == reflect.Float32 {
+		return float64(float32(number)) == number
+	}
+
+	return true
+}
+
+func isNumberKind(kind reflect.Kind) bool {
+	return isIntKind(kind) || isUintKind(kind) || isFloatKind(kind)
+}
+
+func isIntKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	}
+
+	return false
+}
+
+func isUintKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return true
+	}
+
+	return false
+}
+
+func isFloatKind(kind reflect.Kind) bool {
+	return kind == reflect.Float32 || kind == reflect.Float64
+}
@@ -0,0 +1,129 @@
+package eek
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestCoerce(t *testing.T) {
+	coerceInto := func(value interface{}, target interface{}, policy Coercion) (interface{}, error) {
+		result, err := coerce(reflect.ValueOf(value), reflect.TypeOf(target), policy)
+		return result.Interface(), err
+	}
+
+	Convey("Numeric widening", t, func() {
+		result, err := coerceInto(int32(2), int64(0), CoerceNumericWidening)
+		So(err, ShouldBeNil)
+		So(result, ShouldEqual, int64(2))
+
+		result, err = coerceInto(2, float64(0), CoerceNumericWidening)
+		So(err, ShouldBeNil)
+		So(result, ShouldEqual, 2.0)
+
+		result, _ = coerceInto(int64(2), int8(0), CoerceNumericWidening)
+		So(result, ShouldEqual, int64(2))
+
+		_, err = coerceInto(1<<62+1, float64(0), CoerceNumericWidening)
+		So(err, ShouldBeError)
+	})
+
+	Convey("Numeric narrowing", t, func() {
+		result, err := coerceInto(int64(100), int8(0), CoerceNumericNarrowing)
+		So(err, ShouldBeNil)
+		So(result, ShouldEqual, int8(100))
+
+		_, err = coerceInto(300, int8(0), CoerceNumericNarrowing)
+		So(err, ShouldBeError)
+		So(err.Error(), ShouldEqual, "value 300 cannot be represented by type int8")
+
+		result, err = coerceInto(2.0, 0, CoerceNumericNarrowing)
+		So(err, ShouldBeNil)
+		So(result, ShouldEqual, 2)
+
+		_, err = coerceInto(2.5, 0, CoerceNumericNarrowing)
+		So(err, ShouldBeError)
+
+		_, err = coerceInto(-1, uint(0), CoerceNumericNarrowing)
+		So(err, ShouldBeError)
+	})
+
+	Convey("String parsing", t, func() {
+		result, err := coerceInto("12.5", float64(0), CoerceString)
+		So(err, ShouldBeNil)
+		So(result, ShouldEqual, 12.5)
+
+		result, err = coerceInto("true", false, CoerceString)
+		So(err, ShouldBeNil)
+		So(result, ShouldEqual, true)
+
+		result, err = coerceInto("1m30s", time.Duration(0), CoerceString)
+		So(err, ShouldBeNil)
+		So(result, ShouldEqual, 90*time.Second)
+
+		result, err = coerceInto("2019-08-01T00:00:00Z", time.Time{}, CoerceString)
+		So(err, ShouldBeNil)
+		So(result, ShouldResemble, time.Date(2019, 8, 1, 0, 0, 0, 0, time.UTC))
+
+		_, err = coerceInto("300", int8(0), CoerceString)
+		So(err, ShouldBeError)
+	})
+
+	Convey("JSON number and slices", t, func() {
+		result, err := coerceInto(json.Number("42"), 0, CoerceJSONNumber)
+		So(err, ShouldBeNil)
+		So(result, ShouldEqual, 42)
+
+		result, err = coerceInto([]interface{}{1, 2.5, json.Number("3")}, []float64{}, CoerceAll)
+		So(err, ShouldBeNil)
+		So(result, ShouldResemble, []float64{1, 2.5, 3})
+
+		_, err = coerceInto([]interface{}{1, "x"}, []float64{}, CoerceAll)
+		So(err, ShouldBeError)
+		So(err.Error(), ShouldEqual, `index 1: strconv.ParseFloat: parsing "x": invalid syntax`)
+	})
+}
+
+func TestCoercion(t *testing.T) {
+	Convey("Create Eek object with coercion", t, func() {
+		obj := New("coercion")
+		obj.DefineVariable(Var{Name: "B", Type: "float64", Constraint: &Constraint{Max: 100}})
+		obj.DefineVariable(Var{Name: "Values", Type: "[]int"})
+		obj.PrepareEvaluation(`
+			total := B
+			for _, each := range Values {
+				total += float64(each)
+			}
+			return total
+		`)
+
+		Convey("Build operation", func() {
+			err := obj.Build()
+			So(err, ShouldBeNil)
+
+			Convey("Test exec with strict mode", func() {
+				_, err := obj.Evaluate(ExecVar{"B": 2})
+				So(err, ShouldBeError)
+				So(err.Error(), ShouldEqual, "Error on setting value of variable B (type int) with value 2 (type float64)")
+			})
+
+			Convey("Test exec with coercion", func() {
+				obj.Coercion = CoerceAll
+				output, err := obj.Evaluate(ExecVar{"B": "2", "Values": []interface{}{1.0, json.Number("2")}})
+				So(err, ShouldBeNil)
+				So(output, ShouldEqual, 5)
+
+				_, err = obj.Evaluate(ExecVar{"B": "200"})
+				So(err, ShouldBeError)
+				So(err.Error(), ShouldEqual, "variable B must be at most 100, got 200")
+
+				_, err = obj.Evaluate(ExecVar{"Values": []interface{}{1.5}})
+				So(err, ShouldBeError)
+				So(err.Error(), ShouldEqual, "Error on coercing value [1.5] (type []interface {}) of variable Values. index 0: value 1.5 cannot be represented by type int")
+			})
+		})
+	})
+}
@@ -0,0 +1,220 @@
+package eek
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+)
+
+// Constraint is reflect to the rules that the value of a variable must satisfy. It's checked on evaluation before the value is set
+type Constraint struct {
+	// Min and Max are the bounds of numeric value, nil means unbounded
+	Min interface{}
+	Max interface{}
+
+	// Enum is list of allowed values
+	Enum []interface{}
+
+	// Pattern is regular expression that string value must match
+	Pattern string
+
+	// NotNil require the value to be supplied and not nil. Variable that has default value is allowed to be omitted
+	NotNil bool
+
+	// MaxLength is the maximum length of string, slice, array, or map value. Zero means unlimited
+	MaxLength int
+}
+
+// ConstraintViolation is reflect to a single value that does not satisfy the constraint of its variable
+type ConstraintViolation struct {
+	Variable   string
+	Constraint string
+	Message    string
+}
+
+// ConstraintError is returned on evaluation when one or more values do not satisfy the constraints, it holds all of them
+type ConstraintError struct {
+	Violations []ConstraintViolation
+}
+
+func (e *ConstraintError) Error() string {
+	messages := make([]string, 0)
+	for _, each := range e.Violations {
+		messages = append(messages, each.Message)
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// Variables returns the defined variables, including their constraints
+func (e *Eek) Variables() []Var {
+	variables := make([]Var, len(e.variables))
+	copy(variables, e.variables)
+	return variables
+}
+
+// checkConstraints check the values of data against the constraints of the defined variables
+func (e *Eek) checkConstraints(data ExecVar) error {
+	violations := make([]ConstraintViolation, 0)
+
+	for _, each := range e.variables {
+		if each.Constraint == nil {
+			continue
+		}
+
+		value, ok := data[each.Name]
+		if !ok && each.Source != nil {
+			// lazy value is checked when it is fetched
+			continue
+		}
+		violations = append(violations, each.Constraint.check(each, value, ok)...)
+	}
+
+	if len(violations) > 0 {
+		return &ConstraintError{Violations: violations}
+	}
+
+	return nil
+}
+
+func (c *Constraint) check(variable Var, value interface{}, isSupplied bool) []ConstraintViolation {
+	violations := make([]ConstraintViolation, 0)
+	report := func(constraint, format string, args ...interface{}) {
+		violations = append(violations, ConstraintViolation{
+			Variable:   variable.Name,
+			Constraint: constraint,
+			Message:    fmt.Sprintf("variable %s %s", variable.Name, fmt.Sprintf(format, args...)),
+		})
+	}
+
+	if !isSupplied || isNilValue(value) {
+		if c.NotNil && (isSupplied || variable.DefaultValue == nil) {
+			report("notNil", "is required")
+		}
+		return violations
+	}
+
+	if c.Min != nil || c.Max != nil {
+		if number, ok := toFloat64(value); !ok {
+			report("min", "must be a number, got %v (type %T)", value, value)
+		} else {
+			if min, ok := toFloat64(c.Min); ok && number < min {
+				report("min", "must be at least %v, got %v", c.Min, value)
+			}
+			if max, ok := toFloat64(c.Max); ok && number > max {
+				report("max", "must be at most %v, got %v", c.Max, value)
+			}
+		}
+	}
+
+	if len(c.Enum) > 0 {
+		found := false
+		for _, each := range c.Enum {
+			if isEqualValue(each, value) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			report("enum", "must be one of %v, got %v", c.Enum, value)
+		}
+	}
+
+	if c.Pattern != "" {
+		if str, ok := value.(string); !ok {
+			report("pattern", "must be a string, got %v (type %T)", value, value)
+		} else if matched, err := regexp.MatchString(c.Pattern, str); err != nil || !matched {
+			report("pattern", "must match pattern %s, got %q", c.Pattern, str)
+		}
+	}
+
+	if c.MaxLength > 0 {
+		if length, ok := lengthOf(value); !ok {
+			report("maxLength", "must be a string, slice, array, or map, got %v (type %T)", value, value)
+		} else if length > c.MaxLength {
+			report("maxLength", "must have length at most %d, got %d", c.MaxLength, length)
+		}
+	}
+
+	return violations
+}
+
+// validate returns problems of the constraint definition itself
+func (c *Constraint) validate(name string) []string {
+	problems := make([]string, 0)
+
+	min, isMinNumber := toFloat64(c.Min)
+	if c.Min != nil && !isMinNumber {
+		problems = append(problems, fmt.Sprintf("constraint min of variable %s must be a number", name))
+	}
+	max, isMaxNumber := toFloat64(c.Max)
+	if c.Max != nil && !isMaxNumber {
+		problems = append(problems, fmt.Sprintf("constraint max of variable %s must be a number", name))
+	}
+	if isMinNumber && isMaxNumber && min > max {
+		problems = append(problems, fmt.Sprintf("constraint min of variable %s is greater than max", name))
+	}
+
+	if _, err := regexp.Compile(c.Pattern); err != nil {
+		problems = append(problems, fmt.Sprintf("constraint pattern of variable %s is invalid. %s", name, err.Error()))
+	}
+	if c.MaxLength < 0 {
+		problems = append(problems, fmt.Sprintf("constraint maxLength of variable %s cannot be negative", name))
+	}
+
+	return problems
+}
+
+func isNilValue(value interface{}) bool {
+	if value == nil {
+		return true
+	}
+
+	switch v := reflect.ValueOf(value); v.Kind() {
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice:
+		return v.IsNil()
+	}
+
+	return false
+}
+
+func toFloat64(value interface{}) (float64, bool) {
+	if value == nil {
+		return 0, false
+	}
+
+	switch v := reflect.ValueOf(value); v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+
+	return 0, false
+}
+
+// isEqualValue compare two values, numbers of different types are compared by their value
+func isEqualValue(a, b interface{}) bool {
+	numberA, isNumberA := toFloat64(a)
+	numberB, isNumberB := toFloat64(b)
+	if isNumberA && isNumberB {
+		return numberA == numberB
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+func lengthOf(value interface{}) (int, bool) {
+	switch v := reflect.ValueOf(value); v.Kind() {
+	case reflect.String:
+		return utf8.RuneCountInString(v.String()), true
+	case reflect.Slice, reflect.Array, reflect.Map:
+		return v.Len(), true
+	}
+
+	return 0, false
+}
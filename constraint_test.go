@@ -0,0 +1,65 @@
+package eek
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestConstraint(t *testing.T) {
+	Convey("Create Eek object with constrained variables", t, func() {
+		obj := New("constraints")
+		obj.DefineVariable(Var{Name: "Age", Type: "int", Constraint: &Constraint{Min: 18, Max: 120}})
+		obj.DefineVariable(Var{Name: "Tier", Type: "string", DefaultValue: "silver", Constraint: &Constraint{Enum: []interface{}{"silver", "gold"}, NotNil: true}})
+		obj.DefineVariable(Var{Name: "Code", Type: "string", Constraint: &Constraint{Pattern: "^[A-Z]{3}$"}})
+		obj.DefineVariable(Var{Name: "Items", Type: "[]string", Constraint: &Constraint{NotNil: true, MaxLength: 2}})
+		obj.PrepareEvaluation(`return fmt.Sprintf("%d %s %s %d", Age, Tier, Code, len(Items))`)
+		obj.ImportPackage("fmt")
+
+		So(obj.Variables()[0].Constraint.Max, ShouldEqual, 120)
+
+		Convey("Build operation", func() {
+			err := obj.Build()
+			So(err, ShouldBeNil)
+
+			Convey("Test exec with valid values", func() {
+				output, err := obj.Evaluate(ExecVar{"Age": 20, "Code": "ABC", "Items": []string{"a"}})
+				So(err, ShouldBeNil)
+				So(output, ShouldEqual, "20 silver ABC 1")
+			})
+
+			Convey("Test exec with invalid values", func() {
+				_, err := obj.Evaluate(ExecVar{"Age": 10, "Tier": "bronze", "Code": "abc", "Items": []string{"a", "b", "c"}})
+				So(err, ShouldBeError)
+				So(err.Error(), ShouldEqual, `variable Age must be at least 18, got 10; `+
+					`variable Tier must be one of [silver gold], got bronze; `+
+					`variable Code must match pattern ^[A-Z]{3}$, got "abc"; `+
+					`variable Items must have length at most 2, got 3`)
+
+				constraintErr, ok := err.(*ConstraintError)
+				So(ok, ShouldBeTrue)
+				So(constraintErr.Violations[0], ShouldResemble, ConstraintViolation{
+					Variable:   "Age",
+					Constraint: "min",
+					Message:    "variable Age must be at least 18, got 10",
+				})
+			})
+
+			Convey("Test exec with missing values", func() {
+				_, err := obj.Evaluate(ExecVar{"Age": 20, "Tier": nil})
+				So(err, ShouldBeError)
+				So(err.Error(), ShouldEqual, "variable Tier is required; variable Items is required")
+			})
+		})
+	})
+
+	Convey("Error constraint is invalid", t, func() {
+		obj := New("constraints")
+		obj.DefineVariable(Var{Name: "A", Type: "int", Constraint: &Constraint{Min: 10, Max: "9", Pattern: "("}})
+		obj.PrepareEvaluation(`return A`)
+
+		err := obj.Build()
+		So(err, ShouldBeError)
+		So(err.Error(), ShouldEqual, "constraint max of variable A must be a number; constraint pattern of variable A is invalid. error parsing regexp: missing closing ): `(`")
+	})
+}
@@ -0,0 +1,217 @@
+package eek
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"plugin"
+	"reflect"
+	"sync"
+)
+
+// DataSource supply the value of a lazily resolved variable, e.g. from database or remote config. Fetch is called at most once per evaluation, on the first read of the variable
+type DataSource interface {
+	Fetch(name string) (interface{}, error)
+}
+
+// DataSourceFunc is an adapter to use ordinary function as DataSource
+type DataSourceFunc func(name string) (interface{}, error)
+
+// Fetch calls f(name)
+func (f DataSourceFunc) Fetch(name string) (interface{}, error) {
+	return f(name)
+}
+
+// DataSourceError is returned on evaluation when the value of a lazy variable cannot be fetched, or the fetched value is not valid for the variable
+type DataSourceError struct {
+	Variable string
+	Err      error
+}
+
+func (e *DataSourceError) Error() string {
+	return fmt.Sprintf("cannot fetch variable %s. %s", e.Variable, e.Err.Error())
+}
+
+// Unwrap returns the error of the data source
+func (e *DataSourceError) Unwrap() error {
+	return e.Err
+}
+
+// lazyVariables returns variables that have data source
+func (e *Eek) lazyVariables() []Var {
+	variables := make([]Var, 0)
+	for _, each := range e.variables {
+		if each.Source != nil {
+			variables = append(variables, each)
+		}
+	}
+
+	return variables
+}
+
+// lazyGetterName returns name of the generated function that fetch the lazy variable before it is read
+func lazyGetterName(name string) string {
+	return "eekLazy" + name
+}
+
+// lazyReplacements replace every read of lazy variables with call of their getter. Lazy variables are read-only, assignments are reported
+func (e *Eek) lazyReplacements(s *source, lazyVariables []Var) ([]replacement, []Violation) {
+	replacements := make([]replacement, 0)
+	violations := make([]Violation, 0)
+
+	// identifiers that are not resolved inside the source refer to the package level declarations
+	lazyIdents := make(map[*ast.Ident]bool)
+	for _, ident := range s.file.Unresolved {
+		for _, each := range lazyVariables {
+			if ident.Name == each.Name {
+				lazyIdents[ident] = true
+			}
+		}
+	}
+
+	report := func(expr ast.Expr) {
+		if ident, ok := expr.(*ast.Ident); ok && lazyIdents[ident] {
+			violations = append(violations, Violation{
+				Pos:     s.fset.Position(ident.Pos()),
+				Rule:    "lazy",
+				Message: fmt.Sprintf("lazy variable %s cannot be assigned", ident.Name),
+			})
+			delete(lazyIdents, ident)
+		}
+	}
+
+	ast.Inspect(s.node, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.AssignStmt:
+			for _, each := range n.Lhs {
+				report(each)
+			}
+		case *ast.IncDecStmt:
+			report(n.X)
+		case *ast.UnaryExpr:
+			if n.Op == token.AND {
+				report(n.X)
+			}
+		case *ast.RangeStmt:
+			report(n.Key)
+			report(n.Value)
+		case *ast.Ident:
+			if lazyIdents[n] {
+				offset := s.offset(n.Pos())
+				replacements = append(replacements, replacement{offset, len(n.Name), lazyGetterName(n.Name) + "()"})
+			}
+		}
+
+		return true
+	})
+
+	return replacements, violations
+}
+
+// fetcher fetch lazy variables of a single evaluation. Variables supplied in data are never fetched, the others are fetched once then memoised. Failure is raised as panic of *DataSourceError to stop the formula, and it is recorded as well, so it is returned even when the formula recovers the panic
+type fetcher struct {
+	e       *Eek
+	p       *plugin.Plugin
+	mutex   sync.Mutex
+	fetched map[string]bool
+	err     *DataSourceError
+}
+
+func (e *Eek) newFetcher(p *plugin.Plugin, data ExecVar) *fetcher {
+	f := &fetcher{e: e, p: p, fetched: make(map[string]bool)}
+	for name := range data {
+		f.fetched[name] = true
+	}
+
+	return f
+}
+
+// fetch is bound as EekFetch, it is called before every read of a lazy variable. Once a fetch fails, every next fetch fails with the same error
+func (f *fetcher) fetch(name string) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.err != nil {
+		panic(f.err)
+	}
+	if f.fetched[name] {
+		return
+	}
+
+	for _, each := range f.e.lazyVariables() {
+		if each.Name != name {
+			continue
+		}
+
+		value, err := each.Source.Fetch(name)
+		if err == nil {
+			err = f.e.setLazyVariable(f.p, each, value)
+		}
+		if err != nil {
+			f.err = &DataSourceError{Variable: name, Err: err}
+			panic(f.err)
+		}
+	}
+
+	f.fetched[name] = true
+}
+
+// failure returns the recorded failure, nil when every fetch succeeded
+func (f *fetcher) failure() error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if f.err == nil {
+		return nil
+	}
+
+	return f.err
+}
+
+// setLazyVariable set the fetched value into the plugin, the value is converted and checked the same way as ExecVar values
+func (e *Eek) setLazyVariable(p *plugin.Plugin, variable Var, value interface{}) error {
+	lookedUpVar, err := p.Lookup(variable.Name)
+	if err != nil {
+		return err
+	}
+
+	target := reflect.ValueOf(lookedUpVar).Elem()
+	converted := reflect.Zero(target.Type())
+	if value != nil {
+		converted, err = coerce(reflect.ValueOf(value), target.Type(), e.Coercion)
+		if err != nil {
+			return err
+		} else if !converted.Type().AssignableTo(target.Type()) {
+			return fmt.Errorf("value %v (type %T) is not assignable to type %s", value, value, target.Type())
+		}
+	}
+
+	if variable.Constraint != nil {
+		if violations := variable.Constraint.check(variable, converted.Interface(), true); len(violations) > 0 {
+			return &ConstraintError{Violations: violations}
+		}
+	}
+
+	target.Set(converted)
+	return nil
+}
+
+// callEntry call the evaluation entry. Failure of data source and stop of stream are recovered as error, other panics are not. Go statements are rejected on build along with lazy variables or emit, as panic raised from other goroutine cannot be recovered. Results are empty when the stream is stopped
+func callEntry(entry interface{}) (results []reflect.Value, err error) {
+	defer func() {
+		if recovered := recover(); recovered != nil {
+			switch r := recovered.(type) {
+			case *DataSourceError:
+				err = r
+			case *streamStop:
+				if r.err != ErrStopStream {
+					err = r.err
+				}
+			default:
+				panic(recovered)
+			}
+		}
+	}()
+
+	return reflect.ValueOf(entry).Call(nil), nil
+}
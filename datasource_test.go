@@ -0,0 +1,137 @@
+package eek
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDataSource(t *testing.T) {
+	Convey("Create Eek object with lazy variable", t, func() {
+		fetchCount := 0
+		var fetchErr error
+		source := DataSourceFunc(func(name string) (interface{}, error) {
+			fetchCount++
+			return 1.5, fetchErr
+		})
+
+		obj := New("evaluation_with_lazy_variable")
+		obj.DefineVariable(Var{Name: "UseRate", Type: "bool"})
+		obj.DefineVariable(Var{Name: "Rate", Type: "float64", Source: source, Constraint: &Constraint{Max: 2, NotNil: true}})
+		obj.DefineFunction(Func{Name: "Double", BodyFunction: `func() float64 { return Rate * 2 }`})
+		obj.PrepareEvaluation(`
+			if UseRate {
+				return Rate + Double()
+			}
+			return 0.0
+		`)
+
+		Convey("Build operation", func() {
+			err := obj.Build()
+			So(err, ShouldBeNil)
+
+			Convey("Test exec without reading lazy variable", func() {
+				output, err := obj.Evaluate(ExecVar{"UseRate": false})
+				So(err, ShouldBeNil)
+				So(output, ShouldEqual, 0)
+				So(fetchCount, ShouldEqual, 0)
+			})
+
+			Convey("Test exec reading lazy variable", func() {
+				output, err := obj.Evaluate(ExecVar{"UseRate": true})
+				So(err, ShouldBeNil)
+				So(output, ShouldEqual, 4.5)
+				So(fetchCount, ShouldEqual, 1)
+
+				_, err = obj.Evaluate(ExecVar{"UseRate": true})
+				So(err, ShouldBeNil)
+				So(fetchCount, ShouldEqual, 2)
+			})
+
+			Convey("Test exec with supplied lazy variable", func() {
+				output, err := obj.Evaluate(ExecVar{"UseRate": true, "Rate": 1.0})
+				So(err, ShouldBeNil)
+				So(output, ShouldEqual, 3)
+				So(fetchCount, ShouldEqual, 0)
+			})
+
+			Convey("Test exec with fetch error", func() {
+				fetchErr = errors.New("connection refused")
+				_, err := obj.Evaluate(ExecVar{"UseRate": true})
+				So(err, ShouldBeError)
+				So(err, ShouldHaveSameTypeAs, &DataSourceError{})
+				So(err.Error(), ShouldEqual, "cannot fetch variable Rate. connection refused")
+			})
+
+			Convey("Test exec with invalid fetched value", func() {
+				obj.variables[1].Source = DataSourceFunc(func(name string) (interface{}, error) {
+					return "x", nil
+				})
+				_, err := obj.Evaluate(ExecVar{"UseRate": true})
+				So(err, ShouldBeError)
+				So(err.Error(), ShouldEqual, "cannot fetch variable Rate. value x (type string) is not assignable to type float64")
+
+				obj.variables[1].Source = DataSourceFunc(func(name string) (interface{}, error) {
+					return 3.0, nil
+				})
+				_, err = obj.Evaluate(ExecVar{"UseRate": true})
+				So(err, ShouldBeError)
+				So(err.Error(), ShouldEqual, "cannot fetch variable Rate. variable Rate must be at most 2, got 3")
+			})
+		})
+	})
+
+	Convey("Error assigning lazy variable", t, func() {
+		obj := New("evaluation_with_lazy_variable")
+		obj.DefineVariable(Var{Name: "Rate", Type: "float64", Source: DataSourceFunc(func(name string) (interface{}, error) {
+			return 1.0, nil
+		})})
+		obj.PrepareEvaluation(`
+			Rate = 2
+			return Rate
+		`)
+
+		err := obj.Build()
+		So(err, ShouldBeError)
+		So(err.Error(), ShouldEqual, "Evaluate:1:1: lazy variable Rate cannot be assigned")
+	})
+
+	Convey("Error fetch failure recovered by the formula", t, func() {
+		obj := New("evaluation_with_recovered_fetch")
+		obj.DefineVariable(Var{Name: "Rate", Type: "float64", Source: DataSourceFunc(func(name string) (interface{}, error) {
+			return nil, errors.New("db down")
+		})})
+		obj.PrepareEvaluation(`
+			func() {
+				defer func() { recover() }()
+				_ = Rate
+			}()
+			return 0.0
+		`)
+
+		err := obj.Build()
+		So(err, ShouldBeNil)
+
+		_, err = obj.Evaluate(ExecVar{})
+		So(err, ShouldBeError)
+		So(err, ShouldHaveSameTypeAs, &DataSourceError{})
+		So(err.Error(), ShouldEqual, "cannot fetch variable Rate. db down")
+	})
+
+	Convey("Error go statement along with lazy variable", t, func() {
+		obj := New("evaluation_with_lazy_variable")
+		obj.DisableRestriction(RestrictGoStatement)
+		obj.DefineVariable(Var{Name: "Rate", Type: "float64", Source: DataSourceFunc(func(name string) (interface{}, error) {
+			return 1.0, nil
+		})})
+		obj.PrepareEvaluation(`
+			go func() { _ = Rate }()
+			return 0.0
+		`)
+
+		err := obj.Build()
+		So(err, ShouldBeError)
+		So(err.Error(), ShouldEqual, "Evaluate:1:1: go statement is not allowed")
+	})
+}
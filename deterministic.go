@@ -0,0 +1,188 @@
+package eek
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strings"
+)
+
+const (
+	gubrakPackage = "github.com/novalagung/gubrak"
+)
+
+var (
+	// deterministicRandFunctions is list of math/rand functions that are available as method of the seeded *rand.Rand
+	deterministicRandFunctions = map[string]bool{
+		"ExpFloat64": true, "Float32": true, "Float64": true, "Int": true, "Int31": true, "Int31n": true,
+		"Int63": true, "Int63n": true, "Intn": true, "NormFloat64": true, "Perm": true, "Read": true,
+		"Seed": true, "Shuffle": true, "Uint32": true, "Uint64": true,
+	}
+
+	// nondeterministicTimeFunctions is list of time functions that depend on the wall clock or the scheduler
+	nondeterministicTimeFunctions = map[string]bool{
+		"After": true, "AfterFunc": true, "NewTicker": true, "NewTimer": true,
+		"Since": true, "Sleep": true, "Tick": true, "Until": true,
+	}
+
+	// nondeterministicPackages is list of packages that cannot be imported in deterministic mode
+	nondeterministicPackages = []string{"crypto/rand", "math/rand/v2", "net/...", "os/...", "runtime/...", "syscall"}
+)
+
+// deterministicReplacements shadow calls of the clock and the random source with the ones supplied per evaluation, and report calls of other non-deterministic functions
+func (e *Eek) deterministicReplacements(s *source) ([]replacement, []Violation) {
+	replacements := make([]replacement, 0)
+	violations := make([]Violation, 0)
+
+	imported := make(map[string]string)
+	for _, each := range e.packages {
+		imported[packageName(each)] = each
+	}
+
+	report := func(node ast.Node, message string) {
+		violations = append(violations, Violation{
+			Pos:     s.fset.Position(node.Pos()),
+			Rule:    "deterministic",
+			Message: message,
+		})
+	}
+
+	ast.Inspect(s.node, func(node ast.Node) bool {
+		switch n := node.(type) {
+		case *ast.GoStmt:
+			report(n, "go statement is not deterministic")
+		case *ast.SelectStmt:
+			report(n, "select statement is not deterministic")
+		case *ast.SelectorExpr:
+			pkg, ok := n.X.(*ast.Ident)
+			if !ok || pkg.Obj != nil {
+				return true
+			}
+
+			text := ""
+			switch imported[pkg.Name] {
+			case "time":
+				if n.Sel.Name == "Now" {
+					text = "EekNow"
+				} else if nondeterministicTimeFunctions[n.Sel.Name] {
+					report(n, fmt.Sprintf("time.%s is not deterministic", n.Sel.Name))
+				}
+			case "math/rand":
+				if deterministicRandFunctions[n.Sel.Name] {
+					text = "EekRand." + n.Sel.Name
+				}
+			case gubrakPackage:
+				if n.Sel.Name == "RandomInt" {
+					text = "eekRandomInt"
+				} else if strings.HasPrefix(n.Sel.Name, "Random") {
+					report(n, fmt.Sprintf("%s.%s is not deterministic", pkg.Name, n.Sel.Name))
+				}
+			}
+
+			if text != "" {
+				offset := s.offset(n.Pos())
+				replacements = append(replacements, replacement{offset, s.offset(n.End()) - offset, text})
+			}
+		}
+
+		return true
+	})
+
+	return replacements, violations
+}
+
+func (e *Eek) checkDeterministicImports(code string) error {
+	if !e.Deterministic {
+		return nil
+	}
+
+	violations, err := importViolations(code, "deterministic", func(importPath string) string {
+		for _, each := range nondeterministicPackages {
+			if matchImportPath(each, importPath) {
+				return fmt.Sprintf("import of package %s is not deterministic", importPath)
+			}
+		}
+		return ""
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(violations) > 0 {
+		return &ViolationError{Violations: violations}
+	}
+
+	return nil
+}
+
+// checkDeterministicRanges reject range over maps, as the iteration order of map is random. Range over function is rejected as well, since it might iterate a map, and so is range over expression that cannot be type checked
+func (e *Eek) checkDeterministicRanges() error {
+	if !e.Deterministic {
+		return nil
+	}
+
+	sources, err := e.parseSources()
+	if err != nil {
+		return err
+	}
+
+	// type checking is needed only when there is range statement
+	hasRange := false
+	for _, s := range sources {
+		ast.Inspect(s.node, func(node ast.Node) bool {
+			_, ok := node.(*ast.RangeStmt)
+			hasRange = hasRange || ok
+			return !hasRange
+		})
+	}
+	if !hasRange {
+		return nil
+	}
+
+	checked, err := e.typeCheckSources()
+	if err != nil {
+		return err
+	}
+
+	violations := make([]Violation, 0)
+	checked.inspect(func(node ast.Node) {
+		n, ok := node.(*ast.RangeStmt)
+		if !ok {
+			return
+		}
+
+		message := ""
+		switch typ := checked.info.TypeOf(n.X); {
+		case typ == nil:
+			message = "range over expression of unknown type is not deterministic"
+		case isMapType(typ):
+			message = "range over map is not deterministic"
+		case isSignatureType(typ):
+			message = "range over function is not deterministic"
+		}
+
+		if message != "" {
+			violations = append(violations, Violation{
+				Pos:     checked.fset.Position(n.Pos()),
+				Rule:    "deterministic",
+				Message: message,
+			})
+		}
+	})
+
+	if len(violations) > 0 {
+		return &ViolationError{Violations: violations}
+	}
+
+	return nil
+}
+
+func isMapType(typ types.Type) bool {
+	_, ok := typ.Underlying().(*types.Map)
+	return ok
+}
+
+func isSignatureType(typ types.Type) bool {
+	_, ok := typ.Underlying().(*types.Signature)
+	return ok
+}
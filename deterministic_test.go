@@ -0,0 +1,121 @@
+package eek
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDeterministic(t *testing.T) {
+	Convey("Create Eek object with deterministic evaluation", t, func() {
+		obj := New("deterministic")
+		obj.Deterministic = true
+		obj.ImportPackage("fmt")
+		obj.ImportPackage("math/rand")
+		obj.ImportPackage("time")
+		obj.PrepareEvaluation(`
+			return fmt.Sprintf("%d %d %d", time.Now().Year(), rand.Intn(1000), rand.Intn(1000))
+		`)
+
+		Convey("Build operation", func() {
+			err := obj.Build()
+			So(err, ShouldBeNil)
+
+			Convey("Test replay with same options", func() {
+				options := EvalOptions{Now: time.Date(2019, 8, 1, 0, 0, 0, 0, time.UTC), Seed: 42}
+
+				output1, err := obj.EvaluateWith(ExecVar{}, options)
+				So(err, ShouldBeNil)
+				So(output1, ShouldStartWith, "2019 ")
+
+				output2, err := obj.EvaluateWith(ExecVar{}, options)
+				So(err, ShouldBeNil)
+				So(output2, ShouldEqual, output1)
+
+				output3, err := obj.EvaluateWith(ExecVar{}, EvalOptions{Now: options.Now, Seed: 7})
+				So(err, ShouldBeNil)
+				So(output3, ShouldNotEqual, output1)
+			})
+
+			Convey("Test exec without clock", func() {
+				_, err := obj.Evaluate(ExecVar{})
+				So(err, ShouldBeError)
+				So(err.Error(), ShouldEqual, "deterministic evaluation requires the clock. please set EvalOptions.Now")
+			})
+		})
+	})
+
+	Convey("Shadow gubrak random function", t, func() {
+		obj := New("deterministic")
+		obj.Deterministic = true
+		obj.ImportPackage(gubrakPackage)
+		obj.DefineFunction(Func{Name: "Roll", BodyFunction: `func() int { return gubrak.RandomInt(1, 7) }`})
+		obj.PrepareEvaluation(`return Roll()`)
+
+		rewritten, err := obj.rewriteSources()
+		So(err, ShouldBeNil)
+		So(rewritten.function("Roll", ""), ShouldEqual, `func() int { return eekRandomInt(1, 7) }`)
+		So(rewritten.formula("Evaluate", "return Roll()"), ShouldEqual, "return Roll()")
+	})
+
+	Convey("Error non-deterministic functions", t, func() {
+		obj := New("deterministic")
+		obj.Deterministic = true
+		obj.ImportPackage("time")
+		obj.ImportPackage("crypto/rand")
+		obj.PrepareEvaluation(`
+			time.Sleep(time.Second)
+			b := make([]byte, 1)
+			rand.Read(b)
+			return time.Since(time.Now())
+		`)
+
+		err := obj.Build()
+		So(err, ShouldBeError)
+		So(err.Error(), ShouldEqual, "Evaluate:1:1: time.Sleep is not deterministic; Evaluate:4:11: time.Since is not deterministic")
+
+		obj.PrepareEvaluation(`return time.Now()`)
+		err = obj.Build()
+		So(err, ShouldBeError)
+		So(err.Error(), ShouldEqual, "main.go:4:1: import of package crypto/rand is not deterministic")
+	})
+
+	Convey("Error range over map", t, func() {
+		obj := New("deterministic_range")
+		obj.Deterministic = true
+		obj.PrepareEvaluation(`
+			for _, each := range []string{"a"} {
+				_ = each
+			}
+			m := map[string]int{"a": 1}
+			for k := range m {
+				return k
+			}
+			return ""
+		`)
+
+		err := obj.Build()
+		So(err, ShouldBeError)
+		So(err.Error(), ShouldEqual, "Evaluate:5:4: range over map is not deterministic")
+	})
+
+	Convey("Error formula refers to the support code", t, func() {
+		obj := New("deterministic_support")
+		obj.Deterministic = true
+		obj.ImportPackage("time")
+		obj.PrepareEvaluation(`
+			EekNow = time.Now
+			return EekNow()
+		`)
+
+		err := obj.Build()
+		So(err, ShouldBeError)
+		So(err.Error(), ShouldEqual, "Evaluate:1:1: EekNow is reserved for eek internal use; Evaluate:2:11: EekNow is reserved for eek internal use")
+
+		obj.PrepareEvaluation(`return eektime.Now()`)
+		err = obj.Build()
+		So(err, ShouldBeError)
+		So(err.Error(), ShouldContainSubstring, "undefined: eektime")
+	})
+}
@@ -13,7 +13,9 @@ import (
 	"reflect"
 	"regexp"
 	"runtime"
+	"strconv"
 	"strings"
+	"time"
 )
 
 type eekType int
@@ -23,24 +25,62 @@ const (
 	eekTypeComplex
 )
 
+const (
+	defaultEntryName = "Evaluate"
+)
+
 var (
 	regexFileName = regexp.MustCompile("[^A-Za-z0-9]+")
 )
 
 // Eek is main type used on the evaluation
 type Eek struct {
-	name              string
-	goBinaryPath      string
-	functions         []Func
-	variables         []Var
-	packages          []string
-	evaluationType    eekType
-	evaluationFormula string
-	baseBuildPath     string
-	buildPath         string
-	buildFilePath     string
+	name           string
+	goBinaryPath   string
+	functions      []Func
+	variables      []Var
+	outputs        []Var
+	types          []Type
+	constants      []Const
+	packages       []string
+	evaluationType eekType
+	entries        []entry
+	returnType     string
+	baseBuildPath  string
+	buildPath      string
+	buildFilePath  string
+	fingerprint    string
+	variableSample ExecVar
+	policy         *Policy
+	restrictions   map[Restriction]bool
+	limits         Limits
+	hostFunctions  []hostFunc
 
 	UseCachedBuildForSameFormula bool
+
+	// UseErrorReturn change the signature of the evaluation into (result, error), so the formula is able to return business errors
+	UseErrorReturn bool
+
+	// Deterministic shadow the clock and the random source of the formula with the ones supplied on EvaluateWith, and reject other non-deterministic functions on build. Calls of time.Now, math/rand functions, and gubrak.RandomInt are shadowed, range over maps is rejected
+	Deterministic bool
+
+	// UnknownVariablePolicy define how ExecVar keys that are not declared as variable are handled. Default is UnknownVariableReject
+	UnknownVariablePolicy UnknownVariablePolicy
+
+	// RejectGoroutineLeak fail the evaluation with *GoroutineLeakError when the formula leaves goroutines behind
+	RejectGoroutineLeak bool
+
+	// UseEmit change the formula into emit-style, it calls Emit(value) for every result instead of returning one. The formula returns nothing, or only an error when UseErrorReturn is enabled. Evaluate returns the emitted values as []interface{}, EvaluateStream passes them one by one
+	UseEmit bool
+
+	// Coercion define conversions applied to ExecVar values that are not assignable to their variable. Default is CoerceStrict
+	Coercion Coercion
+}
+
+// entry is reflect to a single evaluation entry point, compiled as a function of the plugin
+type entry struct {
+	name    string
+	formula string
 }
 
 // Func is reflect to a single typed reusable function
@@ -54,11 +94,49 @@ type Var struct {
 	Name         string
 	Type         string
 	DefaultValue interface{}
+	Constraint   *Constraint
+
+	// Optional variable is set back to its default value when it is not supplied, and its presence is checked in the formula using IsSet, e.g. IsSet("Discount")
+	Optional bool
+
+	// Source make the variable lazily resolved, the value is fetched on the first read in each evaluation, unless it is supplied in ExecVar
+	Source DataSource
+}
+
+// Type is reflect to a single type declaration, the definition is any go type expression
+type Type struct {
+	Name       string
+	Definition string
+}
+
+// Const is reflect to a single constant declaration. Type is optional, an untyped constant is declared when it's empty
+type Const struct {
+	Name  string
+	Type  string
+	Value interface{}
 }
 
 // ExecVar is used on defining value in the evaluation
 type ExecVar map[string]interface{}
 
+// EvalOptions is used on defining the behaviour of a single evaluation
+type EvalOptions struct {
+	// Entry is name of the evaluation entry to execute. Default is Evaluate
+	Entry string
+
+	// Now is the clock of the deterministic evaluation, returned by every time.Now call. It is required when Deterministic is enabled
+	Now time.Time
+
+	// Seed is used to seed the random source of the deterministic evaluation
+	Seed int64
+
+	// Stats is filled with the resource usage of the evaluation, when it is not nil
+	Stats *EvalStats
+
+	// Emit is called with every value emitted by the formula, when UseEmit is enabled. See EvaluateStream
+	Emit func(value interface{}) error
+}
+
 // New used to create eek object. This function accept an optional variable that will be used as the evaluation name
 func New(args ...string) *Eek {
 	eek := new(Eek)
@@ -69,6 +147,12 @@ func New(args ...string) *Eek {
 
 	eek.functions = make([]Func, 0)
 	eek.variables = make([]Var, 0)
+	eek.outputs = make([]Var, 0)
+	eek.types = make([]Type, 0)
+	eek.constants = make([]Const, 0)
+	eek.entries = make([]entry, 0)
+	eek.restrictions = make(map[Restriction]bool)
+	eek.hostFunctions = make([]hostFunc, 0)
 	eek.packages = make([]string, 0)
 	eek.evaluationType = eekTypeSimple
 
@@ -149,10 +233,37 @@ func (e *Eek) DefineFunction(fun Func) {
 	e.functions = append(e.functions, fun)
 }
 
-// PrepareEvaluation prepare the layout of evaluation string
-func (e *Eek) PrepareEvaluation(evaluationFormula string) {
+// DefineType used to define types that will be used in the evaluation formula, e.g. DefineType("Tier", "int")
+func (e *Eek) DefineType(name, definition string) {
+	e.types = append(e.types, Type{Name: name, Definition: definition})
+}
+
+// DefineConstant used to define constants that will be used in the evaluation formula, e.g. DefineConstant("Gold", "Tier", 2)
+func (e *Eek) DefineConstant(name, typ string, value interface{}) {
+	e.constants = append(e.constants, Const{Name: name, Type: typ, Value: value})
+}
+
+// SetReturnType set the result type of the evaluation, e.g. SetReturnType("float64"). The formula is checked against it on build. Default is interface{}
+func (e *Eek) SetReturnType(returnType string) {
+	e.returnType = strings.TrimSpace(returnType)
+}
+
+// PrepareEvaluation prepare the layout of evaluation string. This function accept an optional entry name, so multiple evaluations sharing the same definitions are compiled into one plugin. Default entry name is Evaluate
+func (e *Eek) PrepareEvaluation(evaluationFormula string, args ...string) {
+	name := defaultEntryName
+	if len(args) > 0 {
+		name = args[0]
+	}
+
 	e.evaluationType = eekTypeSimple
-	e.evaluationFormula = strings.TrimSpace(evaluationFormula)
+	for i, each := range e.entries {
+		if each.name == name {
+			e.entries[i].formula = strings.TrimSpace(evaluationFormula)
+			return
+		}
+	}
+
+	e.entries = append(e.entries, entry{name: name, formula: strings.TrimSpace(evaluationFormula)})
 }
 
 // Build build the evaluation
@@ -161,10 +272,32 @@ func (e *Eek) Build() error {
 		return fmt.Errorf("name is mandatory")
 	} else if e.evaluationType != eekTypeSimple && e.evaluationType != eekTypeComplex {
 		return fmt.Errorf("evaluationType is invalid")
-	} else if e.evaluationFormula == "" {
+	} else if len(e.entries) == 0 {
 		return fmt.Errorf("evaluation formula cannot be empty")
 	}
 
+	for _, each := range e.entries {
+		if each.formula == "" {
+			return fmt.Errorf("evaluation formula cannot be empty")
+		} else if !isExportedName(each.name) {
+			return fmt.Errorf("evaluation entry name must be exported. %s is invalid", each.name)
+		}
+	}
+
+	if err := e.validate(); err != nil {
+		return err
+	}
+
+	if e.variableSample != nil {
+		if err := e.declareMissingVariables(); err != nil {
+			return err
+		}
+	}
+
+	if err := e.checkLimits(); err != nil {
+		return err
+	}
+
 	var code string
 	var err error
 
@@ -178,8 +311,25 @@ func (e *Eek) Build() error {
 		return err
 	}
 
+	// reject the code before build if it breaks the import policy or the restrictions
+	if err := e.checkPolicy(code); err != nil {
+		return err
+	}
+	if err := e.checkRestrictions(); err != nil {
+		return err
+	}
+	if err := e.checkOutputs(); err != nil {
+		return err
+	}
+	if err := e.checkDeterministicImports(code); err != nil {
+		return err
+	}
+	if err := e.checkDeterministicRanges(); err != nil {
+		return err
+	}
+
 	// write code into temporary file, then build the code as go plugin file
-	if err := e.writeToFileThenBuild(code); err != nil {
+	if err := e.writeToFileThenBuild(code, e.supportFile()); err != nil {
 		return err
 	}
 
@@ -187,19 +337,34 @@ func (e *Eek) Build() error {
 }
 
 func (e *Eek) buildSimpleEvaluation() (string, error) {
+	// formulas and function bodies might be rewritten by the enabled features
+	rewritten, err := e.rewriteSources()
+	if err != nil {
+		return "", err
+	}
+
+	return e.layoutSimpleEvaluation(rewritten), nil
+}
+
+// layoutSimpleEvaluation returns the code of main.go, using the formulas and function bodies of rewritten
+func (e *Eek) layoutSimpleEvaluation(rewritten *rewrittenSources) string {
 	// code base code
 	code := strings.TrimSpace(`
 		package main
 
 		$packages
 
+		$types
+
+		$constants
+
 		$functions
 
 		$variables
 
-		func Evaluate() interface{} {
-			$evaluationFormula
-		}
+		$support
+
+		$entries
 	`)
 
 	// inject packages
@@ -214,6 +379,31 @@ func (e *Eek) buildSimpleEvaluation() (string, error) {
 	packageLayout = fmt.Sprintf(strings.TrimSpace(`import (%s)`), strings.TrimSpace(packageLayout))
 	code = strings.Replace(code, "$packages", packageLayout, 1)
 
+	// inject types
+	typeLayout := ""
+	for _, each := range e.types {
+		definition := strings.TrimSpace(each.Definition)
+		if each.Name == "" || definition == "" {
+			continue
+		}
+
+		typeLayout = fmt.Sprintf("%s\n%s %s", typeLayout, each.Name, definition)
+	}
+	typeLayout = fmt.Sprintf(strings.TrimSpace(`type (%s)`), strings.TrimSpace(typeLayout))
+	code = strings.Replace(code, "$types", typeLayout, 1)
+
+	// inject constants
+	constantLayout := ""
+	for _, each := range e.constants {
+		if each.Name == "" || each.Value == nil {
+			continue
+		}
+
+		constantLayout = fmt.Sprintf("%s\n%s %s = %s", constantLayout, each.Name, each.Type, formatValue(each.Value))
+	}
+	constantLayout = fmt.Sprintf(strings.TrimSpace(`const (%s)`), strings.TrimSpace(constantLayout))
+	code = strings.Replace(code, "$constants", constantLayout, 1)
+
 	// inject functions
 	functionLayout := ""
 	for _, each := range e.functions {
@@ -222,7 +412,7 @@ func (e *Eek) buildSimpleEvaluation() (string, error) {
 			continue
 		}
 
-		functionLayout = fmt.Sprintf("%s\nvar %s = %s", functionLayout, each.Name, bodyFunc)
+		functionLayout = fmt.Sprintf("%s\nvar %s = %s", functionLayout, each.Name, rewritten.function(each.Name, bodyFunc))
 	}
 	code = strings.Replace(code, "$functions", strings.TrimSpace(functionLayout), 1)
 
@@ -233,38 +423,57 @@ func (e *Eek) buildSimpleEvaluation() (string, error) {
 			continue
 		}
 
-		if prefix := strings.ToUpper(string(each.Name[0])); prefix != string(each.Name[0]) {
-			return "", fmt.Errorf("defined variable must be exported. %s must be %s%s", each.Name, prefix, each.Name[1:])
-		}
-
 		if each.DefaultValue == nil {
 			variableLayout = fmt.Sprintf("%s\n%s %s", variableLayout, each.Name, each.Type)
 		} else {
-			switch each.DefaultValue.(type) {
-			case string:
-				variableLayout = fmt.Sprintf("%s\n%s %s = \"%v\"", variableLayout, each.Name, each.Type, each.DefaultValue)
-			default:
-				variableLayout = fmt.Sprintf("%s\n%s %s = %v", variableLayout, each.Name, each.Type, each.DefaultValue)
-			}
+			variableLayout = fmt.Sprintf("%s\n%s %s = %s", variableLayout, each.Name, each.Type, formatValue(each.DefaultValue))
 		}
 	}
 	variableLayout = fmt.Sprintf(strings.TrimSpace(`var (%s)`), strings.TrimSpace(variableLayout))
 	code = strings.Replace(code, "$variables", variableLayout, 1)
 
-	// inject evaluationFormula
-	code = strings.Replace(code, "$evaluationFormula", e.evaluationFormula, 1)
+	// inject support code
+	code = strings.Replace(code, "$support", e.supportLayout(), 1)
+
+	// inject entries, all of them share the same return type. Outputs are declared as named results
+	returnType := e.returnType
+	if returnType == "" {
+		returnType = "interface{}"
+	}
+	if e.UseErrorReturn {
+		returnType = fmt.Sprintf("(%s, error)", returnType)
+	}
+	if len(e.outputs) > 0 {
+		returnType = e.outputsSignature()
+	} else if e.UseEmit && e.UseErrorReturn {
+		returnType = "error"
+	} else if e.UseEmit {
+		returnType = ""
+	}
+
+	entryLayout := ""
+	for _, each := range e.entries {
+		entryLayout = fmt.Sprintf("%s\n\nfunc %s() %s {\n%s%s\n}", entryLayout, each.name, returnType, e.outputsPrologue(), rewritten.formula(each.name, each.formula))
+	}
+	code = strings.Replace(code, "$entries", strings.TrimSpace(entryLayout), 1)
 
-	return code, nil
+	return code
 }
 
 func (e *Eek) buildComplexEvaluation() (string, error) {
 	return "", fmt.Errorf("currently complex evaluation is still not supported")
 }
 
-func (e *Eek) writeToFileThenBuild(code string) error {
+func (e *Eek) writeToFileThenBuild(code, supportCode string) error {
 	name := regexFileName.ReplaceAllString(e.name, "_")
 	e.buildPath = filepath.Join(e.baseBuildPath, name)
-	e.buildFilePath = filepath.Join(e.buildPath, fmt.Sprintf("%s_%s.so", name, e.md5(code)))
+	e.fingerprint = e.md5(code + supportCode)
+	e.buildFilePath = filepath.Join(e.buildPath, fmt.Sprintf("%s_%s.so", name, e.fingerprint))
+
+	// plugin of the very same code is already opened, it will be reused
+	if isPluginLoaded(e.fingerprint) {
+		return nil
+	}
 
 	if e.UseCachedBuildForSameFormula {
 		if e.isPathExists(e.buildFilePath) {
@@ -284,6 +493,14 @@ func (e *Eek) writeToFileThenBuild(code string) error {
 		return err
 	}
 
+	if supportCode != "" {
+		supportFilePath := filepath.Join(e.buildPath, "support.go")
+		err = ioutil.WriteFile(supportFilePath, []byte(supportCode), os.ModePerm)
+		if err != nil {
+			return err
+		}
+	}
+
 	op := fmt.Sprintf("cd %s && %s build -buildmode=plugin -o %s", e.buildPath, e.goBinaryPath, filepath.Base(e.buildFilePath))
 
 	var cmd *exec.Cmd
@@ -306,17 +523,69 @@ func (e *Eek) writeToFileThenBuild(code string) error {
 
 // Evaluate execute using particular data
 func (e *Eek) Evaluate(data ExecVar) (interface{}, error) {
-	if !e.isPathExists(e.buildFilePath) {
-		return nil, fmt.Errorf("build file is not found. please try to rebuild the formula")
+	return e.EvaluateWith(data, EvalOptions{})
+}
+
+// EvaluateNamed execute the entry prepared under a particular name using particular data
+func (e *Eek) EvaluateNamed(entryName string, data ExecVar) (interface{}, error) {
+	return e.EvaluateWith(data, EvalOptions{Entry: entryName})
+}
+
+// EvaluateWith execute using particular data and options
+func (e *Eek) EvaluateWith(data ExecVar, options EvalOptions) (interface{}, error) {
+	entryName := options.Entry
+	if entryName == "" {
+		entryName = defaultEntryName
+	}
+
+	if !e.hasEntry(entryName) {
+		return nil, fmt.Errorf("evaluation entry %s is not found", entryName)
 	}
 
-	// open the build file path
-	p, err := plugin.Open(e.buildFilePath)
+	// the clock cannot be defaulted silently, replay of the evaluation needs the same one
+	if e.Deterministic && options.Now.IsZero() {
+		return nil, fmt.Errorf("deterministic evaluation requires the clock. please set EvalOptions.Now")
+	}
+
+	// only declared variables can be set, other symbols of the plugin are not accessible
+	unknownErr := e.unknownVariables(data)
+	if unknownErr != nil && e.UnknownVariablePolicy == UnknownVariableReject {
+		return nil, unknownErr
+	}
+
+	p, err := e.openPlugin()
+	if err != nil {
+		return nil, err
+	}
+
+	// values are converted following the coercion policy, then checked against the constraints
+	data, err = e.coerceVariables(p, data)
 	if err != nil {
 		return nil, err
 	}
+	if err := e.checkConstraints(data); err != nil {
+		return nil, err
+	}
+
+	// emitted values are collected when there is no callback
+	var emitted []interface{}
+	if e.UseEmit && options.Emit == nil {
+		emitted = make([]interface{}, 0)
+		options.Emit = func(value interface{}) error {
+			emitted = append(emitted, value)
+			return nil
+		}
+	}
+
+	if err := e.resetVariables(p); err != nil {
+		return nil, err
+	}
 
 	for varName, varValue := range data {
+		if !e.isVariableDeclared(varName) {
+			continue
+		}
+
 		lookedUpVar, err := p.Lookup(varName)
 		if err != nil {
 			return nil, err
@@ -340,22 +609,119 @@ func (e *Eek) Evaluate(data ExecVar) (interface{}, error) {
 
 			// line underneath has a protential to generate a panic error
 			// reflect.Set: value of type int is not assignable to type float64
-			reflect.ValueOf(lookedUpVar).Elem().Set(reflect.ValueOf(varValue))
+			value := reflect.ValueOf(varValue)
+			if varValue == nil {
+				// nil value set the variable to its zero value
+				value = reflect.Zero(reflect.TypeOf(lookedUpVar).Elem())
+			}
+			reflect.ValueOf(lookedUpVar).Elem().Set(value)
 		})()
 		if err != nil {
 			return nil, err
 		}
 	}
 
-	lookedUpEvaluate, err := p.Lookup("Evaluate")
+	support, err := e.bindSupport(p, data, options)
+	if err != nil {
+		return nil, err
+	}
+
+	lookedUpEvaluate, err := p.Lookup(entryName)
 	if err != nil {
 		return nil, err
 	}
 
-	result := lookedUpEvaluate.(func() interface{})()
+	var meter *evalMeter
+	if options.Stats != nil || e.RejectGoroutineLeak {
+		meter = startEvalMeter()
+	}
+
+	// the result type of evaluate depends on the defined return type
+	var results []reflect.Value
+	var errCall error
+	call := func() {
+		results, errCall = callEntry(lookedUpEvaluate)
+	}
+	if meter != nil {
+		meter.run(call)
+	} else {
+		call()
+	}
+	if stopped, errSupport := support.failure(); stopped {
+		// the failure is returned even when the formula recovers it
+		results, errCall = nil, errSupport
+	}
+
+	if meter != nil {
+		stats := meter.stop()
+		if options.Stats != nil {
+			*options.Stats = stats
+		}
+		if e.RejectGoroutineLeak && stats.GoroutineLeak {
+			return nil, &GoroutineLeakError{Count: stats.GoroutineDelta}
+		}
+	}
+	if errCall != nil {
+		return nil, errCall
+	}
+	// the error, if any, is placed after the result or after the outputs
+	errIndex := 1
+	if len(e.outputs) > 0 {
+		errIndex = len(e.outputs)
+	} else if e.UseEmit {
+		errIndex = 0
+	}
+	if len(results) > errIndex && !results[errIndex].IsNil() {
+		return nil, &FormulaError{Err: results[errIndex].Interface().(error)}
+	}
+
+	var result interface{}
+	if len(e.outputs) > 0 {
+		result = e.outputsOf(results)
+	} else if e.UseEmit {
+		if emitted != nil {
+			result = emitted
+		}
+	} else {
+		result = results[0].Interface()
+	}
+
+	if unknownErr != nil && e.UnknownVariablePolicy == UnknownVariableCollect {
+		return result, unknownErr
+	}
+
 	return result, nil
 }
 
+// openPlugin open the build file path, or reuse the plugin of the same code
+func (e *Eek) openPlugin() (*plugin.Plugin, error) {
+	if e.fingerprint == "" || (!isPluginLoaded(e.fingerprint) && !e.isPathExists(e.buildFilePath)) {
+		return nil, fmt.Errorf("build file is not found. please try to rebuild the formula")
+	}
+
+	return openPlugin(e.fingerprint, e.buildFilePath)
+}
+
+func (e *Eek) hasEntry(name string) bool {
+	for _, each := range e.entries {
+		if each.name == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// formatValue returns the go literal of a default or constant value
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return strconv.Quote(v)
+	default:
+		return fmt.Sprintf("%v", value)
+	}
+}
+
 func (*Eek) md5(str string) string {
 	hasher := md5.New()
 	hasher.Write([]byte(str))
@@ -45,6 +45,18 @@ func TestEval(t *testing.T) {
 				So(output.(float64), ShouldEqual, 3.1)
 			})
 
+			Convey("Test exec with nil value", func() {
+				var output interface{}
+
+				// nil is bound as the zero value of the variable, not the default value
+				output, err = obj.Evaluate(ExecVar{
+					"A": 1,
+					"B": nil,
+				})
+				So(err, ShouldBeNil)
+				So(output.(float64), ShouldEqual, 1)
+			})
+
 			Convey("Test exec error", func() {
 				_, err = obj.Evaluate(ExecVar{
 					"B": 2,
@@ -191,3 +203,102 @@ func TestComplexEvaluation(t *testing.T) {
 		So(err.Error(), ShouldEqual, "currently complex evaluation is still not supported")
 	})
 }
+
+func TestTypeAndConstant(t *testing.T) {
+	Convey("Create Eek object with types and constants", t, func() {
+		obj := New("types and constants")
+		obj.DefineType("Tier", "int")
+		obj.DefineType("LineItem", "struct { Price float64; Qty int }")
+		obj.DefineConstant("Gold", "Tier", 2)
+		obj.DefineConstant("Label", "", "gold \"tier\"")
+		obj.DefineVariable(Var{Name: "Level", Type: "int"})
+		obj.PrepareEvaluation(`
+			items := []LineItem{{Price: 2.5, Qty: 2}, {Price: 1, Qty: 3}}
+			total := 0.0
+			for _, item := range items {
+				total += item.Price * float64(item.Qty)
+			}
+			if Tier(Level) == Gold {
+				return fmt.Sprintf("%s %.1f", Label, total)
+			}
+			return fmt.Sprintf("%.1f", total)
+		`)
+		obj.ImportPackage("fmt")
+
+		Convey("Build operation", func() {
+			err := obj.Build()
+			So(err, ShouldBeNil)
+
+			output, err := obj.Evaluate(ExecVar{"Level": 2})
+			So(err, ShouldBeNil)
+			So(output, ShouldEqual, `gold "tier" 8.0`)
+
+			output, err = obj.Evaluate(ExecVar{"Level": 1})
+			So(err, ShouldBeNil)
+			So(output, ShouldEqual, "8.0")
+		})
+	})
+
+	Convey("Error defined type must be exported", t, func() {
+		obj := New("test")
+		obj.DefineType("tier", "int")
+		obj.PrepareEvaluation("return 1")
+		err := obj.Build()
+		So(err, ShouldBeError)
+		So(err.Error(), ShouldEqual, "defined type must be exported. tier must be Tier")
+	})
+
+	Convey("Error defined constant must be exported", t, func() {
+		obj := New("test")
+		obj.DefineConstant("gold", "", 2)
+		obj.PrepareEvaluation("return 1")
+		err := obj.Build()
+		So(err, ShouldBeError)
+		So(err.Error(), ShouldEqual, "defined constant must be exported. gold must be Gold")
+	})
+}
+
+func TestNamedEvaluation(t *testing.T) {
+	Convey("Create Eek object with multiple entries", t, func() {
+		obj := New("pricing")
+		obj.DefineVariable(Var{Name: "Price", Type: "float64"})
+		obj.DefineVariable(Var{Name: "Qty", Type: "int"})
+		obj.DefineFunction(Func{Name: "Round", BodyFunction: `func(n float64) float64 { return float64(int(n*100+0.5)) / 100 }`})
+		obj.PrepareEvaluation(`return Round(Price * float64(Qty))`, "Subtotal")
+		obj.PrepareEvaluation(`return Round(Subtotal().(float64) * 0.1)`, "Tax")
+		obj.PrepareEvaluation(`return Round(Subtotal().(float64) + Tax().(float64))`, "Total")
+
+		Convey("Build operation", func() {
+			err := obj.Build()
+			So(err, ShouldBeNil)
+
+			Convey("Test exec each entry", func() {
+				output, err := obj.EvaluateNamed("Subtotal", ExecVar{"Price": 2.5, "Qty": 3})
+				So(err, ShouldBeNil)
+				So(output, ShouldEqual, 7.5)
+
+				output, err = obj.EvaluateNamed("Tax", ExecVar{"Price": 2.5, "Qty": 3})
+				So(err, ShouldBeNil)
+				So(output, ShouldEqual, 0.75)
+
+				output, err = obj.EvaluateNamed("Total", ExecVar{"Price": 2.5, "Qty": 3})
+				So(err, ShouldBeNil)
+				So(output, ShouldEqual, 8.25)
+			})
+
+			Convey("Test exec unknown entry", func() {
+				_, err := obj.Evaluate(ExecVar{})
+				So(err, ShouldBeError)
+				So(err.Error(), ShouldEqual, "evaluation entry Evaluate is not found")
+			})
+		})
+	})
+
+	Convey("Error evaluation entry name must be exported", t, func() {
+		obj := New("test")
+		obj.PrepareEvaluation("return 1", "total")
+		err := obj.Build()
+		So(err, ShouldBeError)
+		So(err.Error(), ShouldEqual, "evaluation entry name must be exported. total is invalid")
+	})
+}
@@ -0,0 +1,68 @@
+package eek
+
+import (
+	"fmt"
+	"plugin"
+	"reflect"
+)
+
+// hostFunc is reflect to a single go function of the host that is callable from the evaluation formula
+type hostFunc struct {
+	name string
+	fn   interface{}
+}
+
+// RegisterHostFunc make a go function of the host callable from the evaluation formula, e.g. RegisterHostFunc("LookupRate", func(currency string) float64 { ... }). The function is declared as typed variable in the plugin and bound on every evaluation
+func (e *Eek) RegisterHostFunc(name string, fn interface{}) {
+	e.hostFunctions = append(e.hostFunctions, hostFunc{name: name, fn: fn})
+}
+
+// signature returns the go type of the host function, along with the import paths needed to declare it
+func (h hostFunc) signature() (string, []string, error) {
+	if h.fn == nil || reflect.TypeOf(h.fn).Kind() != reflect.Func {
+		return "", nil, fmt.Errorf("host function %s must be a function", h.name)
+	}
+
+	typ, imports, err := typeExpr(reflect.TypeOf(h.fn))
+	if err != nil {
+		return "", nil, fmt.Errorf("host function %s has unsupported signature. %s", h.name, err.Error())
+	}
+
+	return typ, imports, nil
+}
+
+// hostFuncImports returns import paths needed by signatures of the host functions
+func (e *Eek) hostFuncImports() []string {
+	imports := make([]string, 0)
+	for _, each := range e.hostFunctions {
+		_, paths, err := each.signature()
+		if err != nil {
+			continue
+		}
+
+		for _, path := range paths {
+			imports = appendUnique(imports, path)
+		}
+	}
+
+	return imports
+}
+
+// bindHostFunctions set every host function into the plugin, after the signature is checked against the declaration
+func (e *Eek) bindHostFunctions(p *plugin.Plugin) error {
+	for _, each := range e.hostFunctions {
+		lookedUpVar, err := p.Lookup(each.name)
+		if err != nil {
+			return err
+		}
+
+		declared := reflect.TypeOf(lookedUpVar).Elem()
+		if actual := reflect.TypeOf(each.fn); actual != declared {
+			return fmt.Errorf("host function %s has signature %s, but it is declared as %s. please try to rebuild the formula", each.name, actual, declared)
+		}
+
+		reflect.ValueOf(lookedUpVar).Elem().Set(reflect.ValueOf(each.fn))
+	}
+
+	return nil
+}
@@ -0,0 +1,74 @@
+package eek
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestHostFunc(t *testing.T) {
+	Convey("Create Eek object with host functions", t, func() {
+		rates := map[string]float64{"USD": 1, "EUR": 1.1}
+
+		obj := New("host functions")
+		obj.DefineVariable(Var{Name: "Amount", Type: "float64"})
+		obj.DefineVariable(Var{Name: "Currency", Type: "string"})
+		obj.RegisterHostFunc("LookupRate", func(currency string) (float64, error) {
+			rate, ok := rates[currency]
+			if !ok {
+				return 0, fmt.Errorf("unknown currency %s", currency)
+			}
+			return rate, nil
+		})
+		obj.RegisterHostFunc("Today", func() time.Time {
+			return time.Date(2019, 8, 1, 0, 0, 0, 0, time.UTC)
+		})
+		obj.PrepareEvaluation(`
+			rate, err := LookupRate(Currency)
+			if err != nil {
+				return err.Error()
+			}
+			return Today().Format("2006-01-02") + " " + strconv.FormatFloat(Amount*rate, 'f', 2, 64)
+		`)
+		obj.ImportPackage("strconv")
+
+		Convey("Build operation", func() {
+			err := obj.Build()
+			So(err, ShouldBeNil)
+
+			Convey("Test exec", func() {
+				output, err := obj.Evaluate(ExecVar{"Amount": 10.0, "Currency": "EUR"})
+				So(err, ShouldBeNil)
+				So(output, ShouldEqual, "2019-08-01 11.00")
+
+				output, err = obj.Evaluate(ExecVar{"Amount": 10.0, "Currency": "IDR"})
+				So(err, ShouldBeNil)
+				So(output, ShouldEqual, "unknown currency IDR")
+			})
+
+			Convey("Test host function cannot be set through ExecVar", func() {
+				_, err := obj.Evaluate(ExecVar{"LookupRate": nil})
+				So(err, ShouldBeError)
+				So(err.Error(), ShouldEqual, "variable LookupRate is not declared")
+			})
+		})
+	})
+
+	Convey("Error host function is invalid", t, func() {
+		type local struct{}
+
+		obj := New("host functions")
+		obj.RegisterHostFunc("lookup", func() {})
+		obj.RegisterHostFunc("NotFunction", 12)
+		obj.RegisterHostFunc("Local", func() local { return local{} })
+		obj.PrepareEvaluation(`return 1`)
+
+		err := obj.Build()
+		So(err, ShouldBeError)
+		So(err.Error(), ShouldEqual, "defined host function must be exported. lookup must be Lookup; "+
+			"host function NotFunction must be a function; "+
+			"host function Local has unsupported signature. type eek.local is not accessible from the evaluation")
+	})
+}
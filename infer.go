@@ -0,0 +1,206 @@
+package eek
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Definitions is reflect to the types and variables inferred from a sample
+type Definitions struct {
+	Types     []Type
+	Variables []Var
+}
+
+// String returns the definitions as go declarations, for review
+func (d *Definitions) String() string {
+	lines := make([]string, 0)
+	for _, each := range d.Types {
+		lines = append(lines, fmt.Sprintf("type %s %s\n", each.Name, each.Definition))
+	}
+	for _, each := range d.Variables {
+		lines = append(lines, fmt.Sprintf("var %s %s", each.Name, each.Type))
+	}
+
+	return strings.Join(lines, "\n")
+}
+
+// InferDefinitions returns a variable for each key of the sample JSON object. Objects are declared as struct types named <Name>Type with json tags of the original keys, and arrays are typed after their elements. Numbers are inferred as float64, and values that are null or have mixed types are inferred as interface{}
+func InferDefinitions(sample []byte) (*Definitions, error) {
+	document := make(map[string]interface{})
+	if err := json.Unmarshal(sample, &document); err != nil {
+		return nil, fmt.Errorf("sample must be a JSON object. %s", err.Error())
+	}
+
+	// variables and types share the package scope. Variable names are taken first, as they are matched against the JSON keys on evaluation
+	inferrer := &definitionInferrer{definitions: new(Definitions), names: newIdentifierSet()}
+	keys := sortedKeys(document)
+	names := make([]string, len(keys))
+	for index, key := range keys {
+		names[index] = inferrer.names.add(exportedIdentifier(key))
+	}
+	for index, key := range keys {
+		inferrer.definitions.Variables = append(inferrer.definitions.Variables, Var{
+			Name: names[index],
+			Type: inferrer.typeOf(names[index], []interface{}{document[key]}),
+		})
+	}
+
+	return inferrer.definitions, nil
+}
+
+// DefineFromJSON define the types and variables inferred from the sample JSON object, see InferDefinitions. The definitions are returned for review
+func (e *Eek) DefineFromJSON(sample []byte) (*Definitions, error) {
+	definitions, err := InferDefinitions(sample)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, each := range definitions.Types {
+		e.DefineType(each.Name, each.Definition)
+	}
+	for _, each := range definitions.Variables {
+		e.DefineVariable(each)
+	}
+
+	return definitions, nil
+}
+
+// definitionInferrer collect struct types while the sample is walked
+type definitionInferrer struct {
+	definitions *Definitions
+	names       identifierSet // names of the variables and the types
+}
+
+// typeOf returns type that is able to hold every one of the values, which are the values of the same key or the elements of the same array
+func (i *definitionInferrer) typeOf(name string, values []interface{}) string {
+	kind := ""
+	for _, each := range values {
+		if each == nil {
+			continue
+		}
+
+		eachKind := fmt.Sprintf("%T", each)
+		if kind != "" && kind != eachKind {
+			return "interface{}"
+		}
+		kind = eachKind
+	}
+
+	switch kind {
+	case "bool", "float64", "string":
+		return kind
+	case "[]interface {}":
+		elements := make([]interface{}, 0)
+		for _, each := range values {
+			if each != nil {
+				elements = append(elements, each.([]interface{})...)
+			}
+		}
+		return "[]" + i.typeOf(name, elements)
+	case "map[string]interface {}":
+		return i.structOf(name, values)
+	}
+
+	return "interface{}"
+}
+
+// structOf declare struct type having union of the keys of the objects
+func (i *definitionInferrer) structOf(name string, objects []interface{}) string {
+	keys := make([]string, 0)
+	fieldValues := make(map[string][]interface{})
+	for _, each := range objects {
+		if each == nil {
+			continue
+		}
+		for key, value := range each.(map[string]interface{}) {
+			if _, ok := fieldValues[key]; !ok {
+				keys = append(keys, key)
+			}
+			fieldValues[key] = append(fieldValues[key], value)
+		}
+	}
+	sort.Strings(keys)
+
+	fields := make([]string, 0)
+	names := newIdentifierSet()
+	for _, key := range keys {
+		fieldName := names.add(exportedIdentifier(key))
+		fields = append(fields, fmt.Sprintf("\t%s %s `json:%q`", fieldName, i.typeOf(fieldName, fieldValues[key]), key))
+	}
+	definition := "struct {}"
+	if len(fields) > 0 {
+		definition = fmt.Sprintf("struct {\n%s\n}", strings.Join(fields, "\n"))
+	}
+
+	// name that is already used is numbered, unless it is a type of the same definition
+	typeName := name + "Type"
+	for n := 2; i.names[typeName]; n++ {
+		if existing := i.typeNamed(typeName); existing != nil && existing.Definition == definition {
+			return typeName
+		}
+		typeName = fmt.Sprintf("%sType%d", name, n)
+	}
+
+	i.names[typeName] = true
+	i.definitions.Types = append(i.definitions.Types, Type{Name: typeName, Definition: definition})
+	return typeName
+}
+
+func (i *definitionInferrer) typeNamed(name string) *Type {
+	for index, each := range i.definitions.Types {
+		if each.Name == name {
+			return &i.definitions.Types[index]
+		}
+	}
+
+	return nil
+}
+
+// identifierSet number the identifiers that are already used, e.g. the keys "a" and "A" are named A and A2
+type identifierSet map[string]bool
+
+func newIdentifierSet() identifierSet {
+	return make(identifierSet)
+}
+
+func (s identifierSet) add(name string) string {
+	result := name
+	for n := 2; s[result]; n++ {
+		result = fmt.Sprintf("%s%d", name, n)
+	}
+	s[result] = true
+
+	return result
+}
+
+// exportedIdentifier convert JSON key into exported go identifier, e.g. order_id into OrderId
+func exportedIdentifier(key string) string {
+	words := strings.FieldsFunc(key, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	name := ""
+	for _, each := range words {
+		runes := []rune(each)
+		name += string(unicode.ToUpper(runes[0])) + string(runes[1:])
+	}
+
+	if name == "" || !unicode.IsUpper([]rune(name)[0]) {
+		name = "X" + name
+	}
+
+	return name
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	return keys
+}
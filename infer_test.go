@@ -0,0 +1,87 @@
+package eek
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestInferDefinitions(t *testing.T) {
+	sample := []byte(`{
+		"order_id": "A-1",
+		"paid": true,
+		"items": [
+			{"name": "a", "price": 10, "tags": ["x"]},
+			{"name": "b", "price": 5.5, "discount": null}
+		],
+		"customer": {"name": "c", "address": {"city": "d"}},
+		"extra": null,
+		"mixed": [1, "x"]
+	}`)
+
+	Convey("Infer definitions from sample", t, func() {
+		definitions, err := InferDefinitions(sample)
+		So(err, ShouldBeNil)
+		So(definitions.String(), ShouldEqual, "type AddressType struct {\n"+
+			"\tCity string `json:\"city\"`\n"+
+			"}\n\n"+
+			"type CustomerType struct {\n"+
+			"\tAddress AddressType `json:\"address\"`\n"+
+			"\tName string `json:\"name\"`\n"+
+			"}\n\n"+
+			"type ItemsType struct {\n"+
+			"\tDiscount interface{} `json:\"discount\"`\n"+
+			"\tName string `json:\"name\"`\n"+
+			"\tPrice float64 `json:\"price\"`\n"+
+			"\tTags []string `json:\"tags\"`\n"+
+			"}\n\n"+
+			"var Customer CustomerType\n"+
+			"var Extra interface{}\n"+
+			"var Items []ItemsType\n"+
+			"var Mixed []interface{}\n"+
+			"var OrderId string\n"+
+			"var Paid bool")
+
+		_, err = InferDefinitions([]byte(`[1]`))
+		So(err, ShouldBeError)
+	})
+
+	Convey("Infer variable named after the type of other variable", t, func() {
+		obj := New("evaluation_with_inferred_definitions")
+		definitions, err := obj.DefineFromJSON([]byte(`{"customer": {"name": "c"}, "customer_type": "vip"}`))
+		So(err, ShouldBeNil)
+		So(definitions.String(), ShouldEqual, "type CustomerType2 struct {\n"+
+			"\tName string `json:\"name\"`\n"+
+			"}\n\n"+
+			"var Customer CustomerType2\n"+
+			"var CustomerType string")
+
+		obj.PrepareEvaluation(`return Customer.Name + CustomerType`)
+		So(obj.validate(), ShouldBeNil)
+	})
+
+	Convey("Create Eek object with definitions from sample", t, func() {
+		obj := New("evaluation_with_inferred_definitions")
+		_, err := obj.DefineFromJSON(sample)
+		So(err, ShouldBeNil)
+		obj.PrepareEvaluation(`
+			total := 0.0
+			for _, each := range Items {
+				total += each.Price
+			}
+			return Customer.Address.City + ":" + OrderId + ":" + fmt.Sprint(total)
+		`)
+		obj.ImportPackage("fmt")
+
+		Convey("Build operation", func() {
+			err := obj.Build()
+			So(err, ShouldBeNil)
+
+			Convey("Test exec with sample", func() {
+				output, err := obj.EvaluateJSON(sample)
+				So(err, ShouldBeNil)
+				So(string(output), ShouldEqual, `"d:A-1:15.5"`)
+			})
+		})
+	})
+}
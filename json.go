@@ -0,0 +1,93 @@
+package eek
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// JSONDecodeError is returned by EvaluateJSON when a value cannot be decoded into the type of its variable
+type JSONDecodeError struct {
+	// Path is the path of the offending field, starts with the variable name, e.g. Order.items.price
+	Path string
+	Err  error
+}
+
+func (e *JSONDecodeError) Error() string {
+	return fmt.Sprintf("cannot decode %s: %s", e.Path, e.Err.Error())
+}
+
+// Unwrap returns the underlying decoding error
+func (e *JSONDecodeError) Unwrap() error {
+	return e.Err
+}
+
+// EvaluateJSON execute using data decoded from JSON object, every key is decoded into the type of its variable. The result is returned as JSON
+func (e *Eek) EvaluateJSON(input []byte) ([]byte, error) {
+	p, err := e.openPlugin()
+	if err != nil {
+		return nil, err
+	}
+
+	document := make(map[string]json.RawMessage)
+	if err := json.Unmarshal(input, &document); err != nil {
+		return nil, fmt.Errorf("input must be a JSON object. %s", err.Error())
+	}
+
+	data := make(ExecVar)
+	for key, raw := range document {
+		varName := e.variableNameOf(key)
+		if varName == "" {
+			// unknown keys are handled by the unknown variable policy
+			data[key] = raw
+			continue
+		}
+
+		lookedUpVar, err := p.Lookup(varName)
+		if err != nil {
+			return nil, err
+		}
+
+		value := reflect.New(reflect.TypeOf(lookedUpVar).Elem())
+		if err := json.Unmarshal(raw, value.Interface()); err != nil {
+			path := varName
+			if typeErr, ok := err.(*json.UnmarshalTypeError); ok && typeErr.Field != "" {
+				path = fmt.Sprintf("%s.%s", varName, typeErr.Field)
+			}
+			return nil, &JSONDecodeError{Path: path, Err: err}
+		}
+		data[varName] = value.Elem().Interface()
+	}
+
+	result, err := e.Evaluate(data)
+	if !e.isResultAvailable(err) {
+		return nil, err
+	}
+
+	output, errEncode := json.Marshal(result)
+	if errEncode != nil {
+		return nil, fmt.Errorf("cannot encode result of evaluation. %s", errEncode.Error())
+	}
+
+	return output, err
+}
+
+// variableNameOf returns name of the variable that match the JSON key. Like encoding/json, exact match is preferred over case-insensitive match. Keys that are not go identifier, e.g. order_id, are matched to the variable named by InferDefinitions, e.g. OrderId
+func (e *Eek) variableNameOf(key string) string {
+	if e.isVariableDeclared(key) {
+		return key
+	}
+
+	for _, each := range e.variables {
+		if strings.EqualFold(each.Name, key) {
+			return each.Name
+		}
+	}
+
+	if name := exportedIdentifier(key); e.isVariableDeclared(name) {
+		return name
+	}
+
+	return ""
+}
@@ -0,0 +1,59 @@
+package eek
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestEvaluateJSON(t *testing.T) {
+	Convey("Create Eek object with JSON input", t, func() {
+		obj := New("evaluation_with_json")
+		obj.DefineType("Item", "struct {\n Name string `json:\"name\"`\n Price float64 `json:\"price\"`\n}")
+		obj.DefineVariable(Var{Name: "Items", Type: "[]Item"})
+		obj.DefineVariable(Var{Name: "Discount", Type: "float64"})
+		obj.PrepareEvaluation(`
+			total := 0.0
+			for _, each := range Items {
+				total += each.Price
+			}
+			return map[string]interface{}{"total": total - Discount, "count": len(Items)}
+		`)
+
+		Convey("Build operation", func() {
+			err := obj.Build()
+			So(err, ShouldBeNil)
+
+			Convey("Test exec with JSON object", func() {
+				output, err := obj.EvaluateJSON([]byte(`{"Items": [{"name": "a", "price": 10}, {"name": "b", "price": 5.5}], "Discount": 2}`))
+				So(err, ShouldBeNil)
+				So(string(output), ShouldEqual, `{"count":2,"total":13.5}`)
+			})
+
+			Convey("Test exec with case-insensitive key", func() {
+				output, err := obj.EvaluateJSON([]byte(`{"items": [{"name": "a", "price": 10}], "discount": 3}`))
+				So(err, ShouldBeNil)
+				So(string(output), ShouldEqual, `{"count":1,"total":7}`)
+			})
+
+			Convey("Test exec with invalid field", func() {
+				_, err := obj.EvaluateJSON([]byte(`{"Items": [{"name": "a", "price": "10"}]}`))
+				So(err, ShouldBeError)
+				So(err, ShouldHaveSameTypeAs, &JSONDecodeError{})
+				So(err.(*JSONDecodeError).Path, ShouldStartWith, "Items.")
+				So(err.(*JSONDecodeError).Path, ShouldEndWith, ".price")
+			})
+
+			Convey("Test exec with invalid document", func() {
+				_, err := obj.EvaluateJSON([]byte(`[1, 2]`))
+				So(err, ShouldBeError)
+			})
+
+			Convey("Test exec with unknown key", func() {
+				_, err := obj.EvaluateJSON([]byte(`{"Item": []}`))
+				So(err, ShouldBeError)
+				So(err.Error(), ShouldEqual, "variable Item is not declared, did you mean Items?")
+			})
+		})
+	})
+}
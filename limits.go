@@ -0,0 +1,137 @@
+package eek
+
+import (
+	"fmt"
+	"go/ast"
+)
+
+// Limits is reflect to the complexity limits of the evaluation, checked on build. Zero value of each field means unlimited
+type Limits struct {
+	MaxFormulaBytes int
+	MaxNodes        int
+	MaxDepth        int
+	MaxFunctions    int
+	MaxVariables    int
+	MaxImports      int
+}
+
+// Metrics is reflect to the measured complexity of the evaluation
+type Metrics struct {
+	// FormulaBytes is total length of every evaluation formula
+	FormulaBytes int
+
+	// Nodes is total count of syntax tree nodes of every evaluation formula and defined function
+	Nodes int
+
+	// Depth is the deepest nesting of blocks and function calls
+	Depth int
+
+	Functions int
+	Variables int
+	Imports   int
+}
+
+// LimitError is returned on build when the evaluation exceeds one of the limits
+type LimitError struct {
+	Metric string
+	Limit  int
+	Value  int
+}
+
+func (e *LimitError) Error() string {
+	return fmt.Sprintf("%s of %d exceeds the limit of %d", e.Metric, e.Value, e.Limit)
+}
+
+// SetLimits set the complexity limits of the evaluation
+func (e *Eek) SetLimits(limits Limits) {
+	e.limits = limits
+}
+
+func (e *Eek) measure(sources []*source) Metrics {
+	metrics := Metrics{}
+
+	for _, each := range e.entries {
+		metrics.FormulaBytes += len(each.formula)
+	}
+	for _, each := range e.packages {
+		if each != "" {
+			metrics.Imports++
+		}
+	}
+	metrics.Functions = len(e.functions)
+	metrics.Variables = len(e.variables)
+
+	for _, each := range sources {
+		stack := make([]bool, 0)
+		depth := 0
+
+		ast.Inspect(each.node, func(node ast.Node) bool {
+			if node == nil {
+				if stack[len(stack)-1] {
+					depth--
+				}
+				stack = stack[:len(stack)-1]
+				return false
+			}
+
+			metrics.Nodes++
+
+			// the outermost block of every source is not counted as nesting
+			nested := false
+			switch node.(type) {
+			case *ast.BlockStmt, *ast.CaseClause, *ast.CommClause, *ast.CallExpr:
+				nested = !isOutermostBlock(each, node)
+			}
+
+			if nested {
+				depth++
+				if depth > metrics.Depth {
+					metrics.Depth = depth
+				}
+			}
+			stack = append(stack, nested)
+
+			return true
+		})
+	}
+
+	return metrics
+}
+
+func isOutermostBlock(s *source, node ast.Node) bool {
+	if funcLit, ok := s.node.(*ast.FuncLit); ok {
+		return node == funcLit.Body
+	}
+
+	return node == s.node
+}
+
+func (e *Eek) checkLimits() error {
+	if e.limits == (Limits{}) {
+		return nil
+	}
+
+	sources, err := e.parseSources()
+	if err != nil {
+		return err
+	}
+
+	metrics := e.measure(sources)
+	checks := []LimitError{
+		{"FormulaBytes", e.limits.MaxFormulaBytes, metrics.FormulaBytes},
+		{"Nodes", e.limits.MaxNodes, metrics.Nodes},
+		{"Depth", e.limits.MaxDepth, metrics.Depth},
+		{"Functions", e.limits.MaxFunctions, metrics.Functions},
+		{"Variables", e.limits.MaxVariables, metrics.Variables},
+		{"Imports", e.limits.MaxImports, metrics.Imports},
+	}
+
+	for _, each := range checks {
+		if each.Limit > 0 && each.Value > each.Limit {
+			limitErr := each
+			return &limitErr
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,70 @@
+package eek
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestLimits(t *testing.T) {
+	Convey("Measure complexity of evaluation", t, func() {
+		obj := New("limits")
+		obj.ImportPackage("math")
+		obj.DefineVariable(Var{Name: "N", Type: "int"})
+		obj.DefineFunction(Func{Name: "Abs", BodyFunction: `func(n int) int { if n < 0 { return -n }; return n }`})
+		obj.PrepareEvaluation(`
+			if N > 0 {
+				return math.Sqrt(float64(Abs(N)))
+			}
+			return 0
+		`)
+		obj.SetLimits(Limits{MaxDepth: 10})
+
+		analysis, err := obj.Analyze()
+		So(err, ShouldBeNil)
+		So(analysis.Limits, ShouldResemble, Limits{MaxDepth: 10})
+		So(analysis.Metrics.FormulaBytes, ShouldEqual, 65)
+		So(analysis.Metrics.Depth, ShouldEqual, 4)
+		So(analysis.Metrics.Functions, ShouldEqual, 1)
+		So(analysis.Metrics.Variables, ShouldEqual, 1)
+		So(analysis.Metrics.Imports, ShouldEqual, 1)
+		So(analysis.Metrics.Nodes, ShouldBeGreaterThan, 20)
+
+		Convey("Error depth exceeds the limit", func() {
+			obj.SetLimits(Limits{MaxDepth: 3})
+			err := obj.Build()
+			So(err, ShouldBeError)
+			So(err.Error(), ShouldEqual, "Depth of 4 exceeds the limit of 3")
+
+			limitErr, ok := err.(*LimitError)
+			So(ok, ShouldBeTrue)
+			So(limitErr.Metric, ShouldEqual, "Depth")
+			So(limitErr.Limit, ShouldEqual, 3)
+		})
+
+		Convey("Error first exceeded limit is reported", func() {
+			obj.SetLimits(Limits{MaxImports: 1, MaxVariables: 0, MaxFormulaBytes: 10})
+			err := obj.Build()
+			So(err, ShouldBeError)
+			So(err.Error(), ShouldEqual, "FormulaBytes of 65 exceeds the limit of 10")
+		})
+	})
+
+	Convey("Error code injected after the formula is not measured", t, func() {
+		obj := New("limits")
+		obj.SetLimits(Limits{MaxNodes: 20, MaxDepth: 2})
+		obj.PrepareEvaluation("return 1 }\nfunc Deep() { if true { if true { if true { for i := 0; i < 10; i++ { println(i) } } } } }\nfunc _() {")
+
+		_, err := obj.Analyze()
+		So(err, ShouldBeError)
+		So(err.Error(), ShouldEqual, "Evaluate: formula must be a function body, declarations outside of it are not allowed")
+
+		err = obj.checkLimits()
+		So(err, ShouldBeError)
+		So(err, ShouldNotHaveSameTypeAs, &LimitError{})
+
+		err = obj.Build()
+		So(err, ShouldBeError)
+		So(err.Error(), ShouldEqual, "evaluation formula Evaluate is invalid. Evaluate: formula must be a function body, declarations outside of it are not allowed")
+	})
+}
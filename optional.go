@@ -0,0 +1,48 @@
+package eek
+
+import (
+	"fmt"
+	"plugin"
+)
+
+const (
+	// isSetFunction is name of the generated function that tells whether a variable is supplied, available when there is optional variable
+	isSetFunction = "IsSet"
+)
+
+// optionalVariables returns variables that are optional
+func (e *Eek) optionalVariables() []Var {
+	variables := make([]Var, 0)
+	for _, each := range e.variables {
+		if each.Optional {
+			variables = append(variables, each)
+		}
+	}
+
+	return variables
+}
+
+// optionalLayout returns the generated code of presence tracking
+func (e *Eek) optionalLayout() string {
+	if len(e.optionalVariables()) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("var EekPresence map[string]bool\nfunc %s(name string) bool {\n\treturn EekPresence[name]\n}", isSetFunction)
+}
+
+// bindPresence set the variables supplied in data, a variable is supplied when it has non-nil value
+func (e *Eek) bindPresence(p *plugin.Plugin, data ExecVar) error {
+	if len(e.optionalVariables()) == 0 {
+		return nil
+	}
+
+	presence := make(map[string]bool)
+	for name, value := range data {
+		if e.isVariableDeclared(name) && value != nil {
+			presence[name] = true
+		}
+	}
+
+	return setSupportVariable(p, "EekPresence", presence)
+}
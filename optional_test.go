@@ -0,0 +1,58 @@
+package eek
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestOptionalVariable(t *testing.T) {
+	Convey("Create Eek object with optional variables", t, func() {
+		obj := New("evaluation_with_optional_variable")
+		obj.DefineVariable(Var{Name: "Price", Type: "float64"})
+		obj.DefineVariable(Var{Name: "Discount", Type: "float64", Optional: true})
+		obj.DefineVariable(Var{Name: "Rate", Type: "float64", DefaultValue: 1, Optional: true})
+		obj.PrepareEvaluation(`
+			if !IsSet("Discount") {
+				return Price * Rate - 1
+			}
+			return (Price - Discount) * Rate
+		`)
+
+		Convey("Build operation", func() {
+			err := obj.Build()
+			So(err, ShouldBeNil)
+
+			Convey("Test exec with supplied optional variable", func() {
+				output, err := obj.Evaluate(ExecVar{"Price": 10.0, "Discount": 0.0, "Rate": 2.0})
+				So(err, ShouldBeNil)
+				So(output, ShouldEqual, 20)
+			})
+
+			Convey("Test exec without optional variable", func() {
+				_, err := obj.Evaluate(ExecVar{"Price": 10.0, "Discount": 5.0, "Rate": 2.0})
+				So(err, ShouldBeNil)
+
+				// values of previous evaluation are reset
+				output, err := obj.Evaluate(ExecVar{"Price": 10.0})
+				So(err, ShouldBeNil)
+				So(output, ShouldEqual, 9)
+
+				output, err = obj.Evaluate(ExecVar{"Price": 10.0, "Discount": nil})
+				So(err, ShouldBeNil)
+				So(output, ShouldEqual, 9)
+			})
+		})
+	})
+
+	Convey("Error function collides with IsSet", t, func() {
+		obj := New("evaluation_with_optional_variable")
+		obj.DefineVariable(Var{Name: "Discount", Type: "float64", Optional: true})
+		obj.DefineFunction(Func{Name: "IsSet", BodyFunction: `func() bool { return true }`})
+		obj.PrepareEvaluation(`return Discount`)
+
+		err := obj.Build()
+		So(err, ShouldBeError)
+		So(err.Error(), ShouldEqual, "support function IsSet collides with function IsSet")
+	})
+}
@@ -0,0 +1,152 @@
+package eek
+
+import (
+	"fmt"
+	"go/ast"
+	"go/token"
+	"reflect"
+	"strings"
+)
+
+// DefineOutput define a named output of the evaluation, e.g. DefineOutput(Var{Name: "Score", Type: "float64"}). Outputs are declared as named results of the formula, the formula assigns them then ends with a bare return. Output that has no default value must be assigned
+func (e *Eek) DefineOutput(output Var) {
+	e.outputs = append(e.outputs, output)
+}
+
+// Outputs returns the defined outputs
+func (e *Eek) Outputs() []Var {
+	outputs := make([]Var, len(e.outputs))
+	copy(outputs, e.outputs)
+	return outputs
+}
+
+// EvaluateOutputs execute using particular data, then returns the outputs keyed by their name. Use EvaluateInto to store the outputs into a struct
+func (e *Eek) EvaluateOutputs(data ExecVar) (map[string]interface{}, error) {
+	if len(e.outputs) == 0 {
+		return nil, fmt.Errorf("there is no output defined")
+	}
+
+	result, err := e.Evaluate(data)
+	if !e.isResultAvailable(err) {
+		return nil, err
+	}
+
+	return result.(map[string]interface{}), err
+}
+
+// outputsSignature returns the named results of the entries
+func (e *Eek) outputsSignature() string {
+	results := make([]string, 0)
+	for _, each := range e.outputs {
+		results = append(results, fmt.Sprintf("%s %s", each.Name, each.Type))
+	}
+	if e.UseErrorReturn {
+		results = append(results, "err error")
+	}
+
+	return fmt.Sprintf("(%s)", strings.Join(results, ", "))
+}
+
+// outputsPrologue returns assignments of the default values, placed before the formula
+func (e *Eek) outputsPrologue() string {
+	prologue := ""
+	for _, each := range e.outputs {
+		if each.DefaultValue != nil {
+			prologue = fmt.Sprintf("%s%s = %s\n", prologue, each.Name, formatValue(each.DefaultValue))
+		}
+	}
+
+	return prologue
+}
+
+// outputsOf returns the outputs of the evaluation keyed by their name
+func (e *Eek) outputsOf(results []reflect.Value) map[string]interface{} {
+	outputs := make(map[string]interface{})
+	for i, each := range e.outputs {
+		if i < len(results) {
+			outputs[each.Name] = results[i].Interface()
+		}
+	}
+
+	return outputs
+}
+
+// checkOutputs reject formula that never assigns an output. An output is assigned by assignment, increment, taking its address, or by a return statement that has values
+func (e *Eek) checkOutputs() error {
+	if len(e.outputs) == 0 {
+		return nil
+	}
+
+	sources, err := e.parseSources()
+	if err != nil {
+		return err
+	}
+
+	violations := make([]Violation, 0)
+	for _, s := range sources {
+		if !s.isFormula {
+			continue
+		}
+
+		assigned := make(map[string]bool)
+		unresolved := make(map[*ast.Ident]bool)
+		for _, ident := range s.file.Unresolved {
+			unresolved[ident] = true
+		}
+		assign := func(expr ast.Expr) {
+			if ident, ok := expr.(*ast.Ident); ok && unresolved[ident] {
+				assigned[ident.Name] = true
+			}
+		}
+
+		ast.Inspect(s.node, func(node ast.Node) bool {
+			switch n := node.(type) {
+			case *ast.AssignStmt:
+				for _, each := range n.Lhs {
+					assign(each)
+				}
+			case *ast.IncDecStmt:
+				assign(n.X)
+			case *ast.UnaryExpr:
+				if n.Op == token.AND {
+					assign(n.X)
+				}
+			case *ast.RangeStmt:
+				assign(n.Key)
+				assign(n.Value)
+			}
+			return true
+		})
+
+		// return statements of function literals do not return the outputs
+		ast.Inspect(s.node, func(node ast.Node) bool {
+			switch n := node.(type) {
+			case *ast.FuncLit:
+				return false
+			case *ast.ReturnStmt:
+				if len(n.Results) > 0 {
+					for _, each := range e.outputs {
+						assigned[each.Name] = true
+					}
+				}
+			}
+			return true
+		})
+
+		for _, each := range e.outputs {
+			if !assigned[each.Name] && each.DefaultValue == nil {
+				violations = append(violations, Violation{
+					Pos:     token.Position{Filename: s.name},
+					Rule:    "output",
+					Message: fmt.Sprintf("output %s is never assigned", each.Name),
+				})
+			}
+		}
+	}
+
+	if len(violations) > 0 {
+		return &ViolationError{Violations: violations}
+	}
+
+	return nil
+}
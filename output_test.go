@@ -0,0 +1,115 @@
+package eek
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type scoreResult struct {
+	Score   float64
+	Reason  string
+	Factors []string
+}
+
+func TestOutput(t *testing.T) {
+	Convey("Create Eek object with outputs", t, func() {
+		obj := New("evaluation_with_outputs")
+		obj.ImportPackage("errors")
+		obj.UseErrorReturn = true
+		obj.DefineVariable(Var{Name: "Amount", Type: "float64"})
+		obj.DefineOutput(Var{Name: "Score", Type: "float64"})
+		obj.DefineOutput(Var{Name: "Reason", Type: "string", DefaultValue: "OK"})
+		obj.DefineOutput(Var{Name: "Factors", Type: "[]string"})
+		obj.PrepareEvaluation(`
+			if Amount < 0 {
+				err = errors.New("amount cannot be negative")
+				return
+			}
+
+			Score = Amount / 10
+			if Amount > 100 {
+				Reason = "HIGH_AMOUNT"
+				Factors = append(Factors, "amount")
+			}
+			return
+		`)
+
+		Convey("Build operation", func() {
+			err := obj.Build()
+			So(err, ShouldBeNil)
+
+			Convey("Test exec with outputs", func() {
+				outputs, err := obj.EvaluateOutputs(ExecVar{"Amount": 150.0})
+				So(err, ShouldBeNil)
+				So(outputs, ShouldResemble, map[string]interface{}{"Score": 15.0, "Reason": "HIGH_AMOUNT", "Factors": []string{"amount"}})
+
+				outputs, err = obj.EvaluateOutputs(ExecVar{"Amount": 50.0})
+				So(err, ShouldBeNil)
+				So(outputs["Reason"], ShouldEqual, "OK")
+			})
+
+			Convey("Test exec into struct", func() {
+				out := scoreResult{}
+				err := obj.EvaluateInto(ExecVar{"Amount": 150.0}, &out)
+				So(err, ShouldBeNil)
+				So(out, ShouldResemble, scoreResult{Score: 15, Reason: "HIGH_AMOUNT", Factors: []string{"amount"}})
+			})
+
+			Convey("Test exec with formula error", func() {
+				_, err := obj.EvaluateOutputs(ExecVar{"Amount": -1.0})
+				So(err, ShouldBeError)
+				So(err, ShouldHaveSameTypeAs, &FormulaError{})
+				So(err.Error(), ShouldEqual, "amount cannot be negative")
+			})
+		})
+	})
+
+	Convey("Error output is never assigned", t, func() {
+		obj := New("evaluation_with_outputs")
+		obj.DefineOutput(Var{Name: "Score", Type: "float64"})
+		obj.DefineOutput(Var{Name: "Reason", Type: "string"})
+		obj.PrepareEvaluation(`
+			Score = 1
+			return
+		`)
+
+		err := obj.Build()
+		So(err, ShouldBeError)
+		So(err.Error(), ShouldEqual, "Evaluate: output Reason is never assigned")
+	})
+
+	Convey("Error output is assigned with other type", t, func() {
+		obj := New("evaluation_with_outputs")
+		obj.DefineOutput(Var{Name: "Score", Type: "float64"})
+		obj.PrepareEvaluation(`
+			Score = "high"
+			return
+		`)
+
+		err := obj.Build()
+		So(err, ShouldBeError)
+	})
+
+	Convey("Error output named err", t, func() {
+		obj := New("evaluation_with_outputs")
+		obj.UseErrorReturn = true
+		obj.DefineOutput(Var{Name: "err", Type: "string"})
+		obj.PrepareEvaluation(`return`)
+
+		err := obj.Build()
+		So(err, ShouldBeError)
+		So(err.Error(), ShouldEqual, "defined output name err is reserved")
+	})
+
+	Convey("Error outputs along with return type", t, func() {
+		obj := New("evaluation_with_outputs")
+		obj.DefineOutput(Var{Name: "Score", Type: "float64"})
+		obj.SetReturnType("float64")
+		obj.PrepareEvaluation(`return 1`)
+
+		err := obj.Build()
+		So(err, ShouldBeError)
+		So(err.Error(), ShouldEqual, "return type cannot be set along with outputs")
+	})
+}
@@ -0,0 +1,147 @@
+package eek
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"strconv"
+	"strings"
+)
+
+// Policy restrict packages that can be imported into the evaluation. Each path is either an exact import path or a pattern ends with "/..." that match the path and all of its sub packages
+type Policy struct {
+	// Allow is list of allowed import paths. When it's empty, every import path is allowed unless it is denied
+	Allow []string
+
+	// Deny is list of denied import paths, it takes precedence over Allow
+	Deny []string
+
+	// StdlibOnly deny every package outside the go standard library
+	StdlibOnly bool
+}
+
+// PolicyPureMath returns policy that only allow packages for pure computation, without any I/O or formatting
+func PolicyPureMath() *Policy {
+	return &Policy{
+		Allow: []string{"errors", "math/...", "sort", "strconv", "strings", "unicode/..."},
+	}
+}
+
+// PolicyStdlibWithoutIO returns policy that allow the go standard library, except packages that able to do I/O or to escape the go type system
+func PolicyStdlibWithoutIO() *Policy {
+	return &Policy{
+		Deny: []string{
+			"database/...", "embed", "io/ioutil", "log/...", "net/...", "os/...",
+			"path/filepath", "plugin", "runtime/...", "syscall", "unsafe",
+		},
+		StdlibOnly: true,
+	}
+}
+
+// Violation is reflect to a single breach of the rules applied to the evaluation, found on build
+type Violation struct {
+	Pos     token.Position
+	Rule    string
+	Message string
+}
+
+func (v Violation) String() string {
+	return fmt.Sprintf("%s: %s", v.Pos, v.Message)
+}
+
+// ViolationError is returned on build when the evaluation breaks one or more rules
+type ViolationError struct {
+	Violations []Violation
+}
+
+func (e *ViolationError) Error() string {
+	messages := make([]string, 0)
+	for _, each := range e.Violations {
+		messages = append(messages, each.String())
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// SetPolicy set the import policy, it's checked on build against the generated code. Passing nil removes the policy
+func (e *Eek) SetPolicy(policy *Policy) {
+	e.policy = policy
+}
+
+func (e *Eek) checkPolicy(code string) error {
+	if e.policy == nil {
+		return nil
+	}
+
+	violations, err := importViolations(code, "import", e.policy.check)
+	if err != nil {
+		return err
+	}
+
+	if len(violations) > 0 {
+		return &ViolationError{Violations: violations}
+	}
+
+	return nil
+}
+
+// importViolations returns every import of the generated main.go that is rejected by the check function. Imports of the support code are placed in its own file, those are not checked
+func importViolations(code string, rule string, check func(importPath string) string) ([]Violation, error) {
+	fset := token.NewFileSet()
+	file, err := parser.ParseFile(fset, "main.go", code, parser.ImportsOnly)
+	if err != nil {
+		return nil, err
+	}
+
+	violations := make([]Violation, 0)
+	for _, each := range file.Imports {
+		importPath, err := strconv.Unquote(each.Path.Value)
+		if err != nil {
+			return nil, err
+		}
+
+		if message := check(importPath); message != "" {
+			violations = append(violations, Violation{
+				Pos:     fset.Position(each.Pos()),
+				Rule:    rule,
+				Message: message,
+			})
+		}
+	}
+
+	return violations, nil
+}
+
+// check returns the reason why the import path is rejected, or an empty string when it is accepted
+func (p *Policy) check(importPath string) string {
+	for _, each := range p.Deny {
+		if matchImportPath(each, importPath) {
+			return fmt.Sprintf("import of package %s is denied by policy", importPath)
+		}
+	}
+
+	if p.StdlibOnly && strings.Contains(strings.Split(importPath, "/")[0], ".") {
+		return fmt.Sprintf("import of package %s is denied by policy, only standard library is allowed", importPath)
+	}
+
+	if len(p.Allow) == 0 {
+		return ""
+	}
+
+	for _, each := range p.Allow {
+		if matchImportPath(each, importPath) {
+			return ""
+		}
+	}
+
+	return fmt.Sprintf("import of package %s is not allowed by policy", importPath)
+}
+
+func matchImportPath(pattern, importPath string) bool {
+	if strings.HasSuffix(pattern, "/...") {
+		prefix := strings.TrimSuffix(pattern, "/...")
+		return importPath == prefix || strings.HasPrefix(importPath, prefix+"/")
+	}
+
+	return pattern == importPath
+}
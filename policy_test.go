@@ -0,0 +1,45 @@
+package eek
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPolicy(t *testing.T) {
+	Convey("Import policy", t, func() {
+		So(PolicyPureMath().check("math/big"), ShouldEqual, "")
+		So(PolicyPureMath().check("fmt"), ShouldEqual, "import of package fmt is not allowed by policy")
+		So(PolicyStdlibWithoutIO().check("fmt"), ShouldEqual, "")
+		So(PolicyStdlibWithoutIO().check("os/exec"), ShouldEqual, "import of package os/exec is denied by policy")
+		So(PolicyStdlibWithoutIO().check("github.com/novalagung/gubrak"), ShouldEqual, "import of package github.com/novalagung/gubrak is denied by policy, only standard library is allowed")
+	})
+
+	Convey("Error import is denied by policy", t, func() {
+		obj := New("policy")
+		obj.SetPolicy(PolicyStdlibWithoutIO())
+		obj.ImportPackage("strings")
+		obj.ImportPackage("os")
+		obj.ImportPackage("unsafe")
+		obj.PrepareEvaluation(`return strings.ToUpper(os.Getenv("HOME")) + string(rune(unsafe.Sizeof(0)))`)
+
+		err := obj.Build()
+		So(err, ShouldBeError)
+		So(err.Error(), ShouldEqual, "main.go:4:1: import of package os is denied by policy; main.go:5:1: import of package unsafe is denied by policy")
+
+		violationErr, ok := err.(*ViolationError)
+		So(ok, ShouldBeTrue)
+		So(len(violationErr.Violations), ShouldEqual, 2)
+		So(violationErr.Violations[0].Rule, ShouldEqual, "import")
+	})
+
+	Convey("Build with allowed imports", t, func() {
+		obj := New("policy")
+		obj.SetPolicy(PolicyPureMath())
+		obj.ImportPackage("math")
+		obj.PrepareEvaluation(`return math.Sqrt(16)`)
+
+		err := obj.Build()
+		So(err, ShouldBeNil)
+	})
+}
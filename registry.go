@@ -0,0 +1,134 @@
+package eek
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"plugin"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var (
+	// plugins is the process wide registry of opened plugins, keyed by fingerprint of the generated code. Go plugin cannot be unloaded, so each fingerprint is opened only once
+	plugins      = make(map[string]*loadedPlugin)
+	pluginsMutex sync.Mutex
+	pluginsLimit int
+)
+
+// loadedPlugin is reflect to a single opened plugin
+type loadedPlugin struct {
+	plugin      *plugin.Plugin
+	path        string
+	mappedBytes int64
+}
+
+// PluginStats is reflect to the plugins opened by the process
+type PluginStats struct {
+	Count       int
+	MappedBytes int64
+}
+
+// PluginLimitError is returned on evaluation when opening the plugin would exceed the limit of loaded plugins
+type PluginLimitError struct {
+	Limit int
+}
+
+func (e *PluginLimitError) Error() string {
+	return fmt.Sprintf("cannot load more plugin, the limit of %d loaded plugins is reached", e.Limit)
+}
+
+// LoadedPlugins returns count of plugins opened by the process and the memory they map
+func LoadedPlugins() PluginStats {
+	pluginsMutex.Lock()
+	defer pluginsMutex.Unlock()
+
+	stats := PluginStats{}
+	for _, each := range plugins {
+		stats.Count++
+		stats.MappedBytes += each.mappedBytes
+	}
+
+	return stats
+}
+
+// SetPluginLimit set the maximum count of plugins opened by the process. Plugins that already opened are still usable after the limit is reached. Zero means unlimited
+func SetPluginLimit(limit int) {
+	pluginsMutex.Lock()
+	defer pluginsMutex.Unlock()
+
+	pluginsLimit = limit
+}
+
+// openPlugin returns the plugin of a particular fingerprint. The plugin is opened from path only when it is not opened yet
+func openPlugin(fingerprint, path string) (*plugin.Plugin, error) {
+	pluginsMutex.Lock()
+	defer pluginsMutex.Unlock()
+
+	if each, ok := plugins[fingerprint]; ok {
+		return each.plugin, nil
+	}
+
+	if pluginsLimit > 0 && len(plugins) >= pluginsLimit {
+		return nil, &PluginLimitError{Limit: pluginsLimit}
+	}
+
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	plugins[fingerprint] = &loadedPlugin{plugin: p, path: path, mappedBytes: mappedBytesOf(path)}
+	return p, nil
+}
+
+// isPluginLoaded returns true when plugin of a particular fingerprint is already opened
+func isPluginLoaded(fingerprint string) bool {
+	pluginsMutex.Lock()
+	defer pluginsMutex.Unlock()
+
+	_, ok := plugins[fingerprint]
+	return ok
+}
+
+// mappedBytesOf returns size of memory mapped from the file. The file size is used when the memory map of the process is not available
+func mappedBytesOf(path string) int64 {
+	if absPath, err := filepath.Abs(path); err == nil {
+		if realPath, err := filepath.EvalSymlinks(absPath); err == nil {
+			path = realPath
+		}
+	}
+
+	if file, err := os.Open("/proc/self/maps"); err == nil {
+		defer file.Close()
+
+		mappedBytes := int64(0)
+		scanner := bufio.NewScanner(file)
+		for scanner.Scan() {
+			// address perms offset dev inode pathname
+			fields := strings.Fields(scanner.Text())
+			if len(fields) < 6 || fields[5] != path {
+				continue
+			}
+
+			addresses := strings.SplitN(fields[0], "-", 2)
+			start, errStart := strconv.ParseUint(addresses[0], 16, 64)
+			end, errEnd := strconv.ParseUint(addresses[1], 16, 64)
+			if errStart == nil && errEnd == nil {
+				mappedBytes += int64(end - start)
+			}
+		}
+
+		if mappedBytes > 0 {
+			return mappedBytes
+		}
+	}
+
+	if info, err := os.Stat(path); err == nil {
+		return info.Size()
+	}
+
+	return 0
+}
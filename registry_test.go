@@ -0,0 +1,58 @@
+package eek
+
+import (
+	"fmt"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPluginRegistry(t *testing.T) {
+	Convey("Reuse plugin of the same code", t, func() {
+		first := New("registry first")
+		first.DefineVariable(Var{Name: "A", Type: "int"})
+		first.PrepareEvaluation(`return A * 3`)
+		So(first.Build(), ShouldBeNil)
+
+		output, err := first.Evaluate(ExecVar{"A": 2})
+		So(err, ShouldBeNil)
+		So(output, ShouldEqual, 6)
+
+		stats := LoadedPlugins()
+		So(stats.Count, ShouldBeGreaterThan, 0)
+		So(stats.MappedBytes, ShouldBeGreaterThan, 0)
+
+		second := New("registry second")
+		second.DefineVariable(Var{Name: "A", Type: "int"})
+		second.PrepareEvaluation(`return A * 3`)
+		So(second.Build(), ShouldBeNil)
+		So(second.fingerprint, ShouldEqual, first.fingerprint)
+
+		output, err = second.Evaluate(ExecVar{"A": 3})
+		So(err, ShouldBeNil)
+		So(output, ShouldEqual, 9)
+		So(LoadedPlugins().Count, ShouldEqual, stats.Count)
+
+		// variable omitted by first is not leaked from the evaluation of second
+		output, err = first.Evaluate(ExecVar{})
+		So(err, ShouldBeNil)
+		So(output, ShouldEqual, 0)
+
+		Convey("Error plugin limit is reached", func() {
+			SetPluginLimit(stats.Count)
+			defer SetPluginLimit(0)
+
+			_, err := first.Evaluate(ExecVar{"A": 1})
+			So(err, ShouldBeNil)
+
+			third := New("registry third")
+			third.DefineVariable(Var{Name: "A", Type: "int"})
+			third.PrepareEvaluation(`return A * 4`)
+			So(third.Build(), ShouldBeNil)
+
+			_, err = third.Evaluate(ExecVar{"A": 1})
+			So(err, ShouldBeError)
+			So(err.Error(), ShouldEqual, fmt.Sprintf("cannot load more plugin, the limit of %d loaded plugins is reached", stats.Count))
+		})
+	})
+}
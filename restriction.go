@@ -0,0 +1,199 @@
+package eek
+
+import (
+	"go/ast"
+	"go/token"
+	"go/types"
+)
+
+// Restriction is reflect to a go language construct that can be forbidden in the evaluation formula and the defined functions
+type Restriction string
+
+const (
+	// RestrictGoStatement forbid go statements
+	RestrictGoStatement Restriction = "go"
+
+	// RestrictChannel forbid channel types, send and receive operations, and select statements
+	RestrictChannel Restriction = "channel"
+
+	// RestrictDefer forbid defer statements and recover calls
+	RestrictDefer Restriction = "defer"
+
+	// RestrictGoto forbid goto statements
+	RestrictGoto Restriction = "goto"
+
+	// RestrictInfiniteLoop forbid for loops without condition
+	RestrictInfiniteLoop Restriction = "infinite-loop"
+
+	// RestrictReflectMethod forbid method values obtained through reflect.Value. The sources are type checked, Method and MethodByName are allowed only when the receiver is proven not to be reflect.Value, e.g. reflect.Type
+	RestrictReflectMethod Restriction = "reflect-method"
+
+	// RestrictInit forbid package level init functions, applicable to complex evaluation and to defined functions
+	RestrictInit Restriction = "init"
+)
+
+// EnableRestriction forbid particular constructs in the evaluation formula and the defined functions. It's checked on build
+func (e *Eek) EnableRestriction(restrictions ...Restriction) {
+	for _, each := range restrictions {
+		e.restrictions[each] = true
+	}
+}
+
+// DisableRestriction allow back particular constructs in the evaluation formula and the defined functions
+func (e *Eek) DisableRestriction(restrictions ...Restriction) {
+	for _, each := range restrictions {
+		delete(e.restrictions, each)
+	}
+}
+
+// isRestricted returns true when the restriction is enabled. Go statements are always restricted when the support functions stop the formula by panic, i.e. on lazy variable fetch or on emit, since panic raised from other goroutine cannot be recovered
+func (e *Eek) isRestricted(restriction Restriction) bool {
+	if restriction == RestrictGoStatement && (e.UseEmit || len(e.lazyVariables()) > 0) {
+		return true
+	}
+
+	return e.restrictions[restriction]
+}
+
+func (e *Eek) checkRestrictions() error {
+	if len(e.restrictions) == 0 && !e.isRestricted(RestrictGoStatement) {
+		return nil
+	}
+
+	sources, err := e.parseSources()
+	if err != nil {
+		return err
+	}
+
+	violations := make([]Violation, 0)
+	report := func(s *source, restriction Restriction, pos token.Pos, message string) {
+		if e.isRestricted(restriction) {
+			violations = append(violations, Violation{
+				Pos:     s.fset.Position(pos),
+				Rule:    string(restriction),
+				Message: message,
+			})
+		}
+	}
+
+	// type checking is needed only when there is selector that might be a reflect method
+	reflectMethods := make(map[string]bool)
+	if e.isRestricted(RestrictReflectMethod) && hasMethodSelector(sources) {
+		reflectMethods, err = e.reflectMethodPositions()
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, s := range sources {
+		s := s
+		if !s.isFormula && s.name == "init" {
+			report(s, RestrictInit, s.node.Pos(), "init function is not allowed")
+		}
+
+		ast.Inspect(s.node, func(node ast.Node) bool {
+			switch n := node.(type) {
+			case *ast.GoStmt:
+				report(s, RestrictGoStatement, n.Pos(), "go statement is not allowed")
+			case *ast.ChanType:
+				report(s, RestrictChannel, n.Pos(), "channel type is not allowed")
+			case *ast.SendStmt:
+				report(s, RestrictChannel, n.Arrow, "channel send is not allowed")
+			case *ast.UnaryExpr:
+				if n.Op == token.ARROW {
+					report(s, RestrictChannel, n.Pos(), "channel receive is not allowed")
+				}
+			case *ast.SelectStmt:
+				report(s, RestrictChannel, n.Pos(), "select statement is not allowed")
+			case *ast.DeferStmt:
+				report(s, RestrictDefer, n.Pos(), "defer statement is not allowed")
+			case *ast.CallExpr:
+				if ident, ok := n.Fun.(*ast.Ident); ok && ident.Name == "recover" && ident.Obj == nil {
+					report(s, RestrictDefer, n.Pos(), "recover is not allowed")
+				}
+			case *ast.BranchStmt:
+				if n.Tok == token.GOTO {
+					report(s, RestrictGoto, n.Pos(), "goto statement is not allowed")
+				}
+			case *ast.ForStmt:
+				if cond, ok := n.Cond.(*ast.Ident); n.Cond == nil || (ok && cond.Name == "true" && cond.Obj == nil) {
+					report(s, RestrictInfiniteLoop, n.Pos(), "for loop without condition is not allowed")
+				}
+			case *ast.SelectorExpr:
+				if reflectMethods[s.fset.Position(n.Sel.Pos()).String()] {
+					report(s, RestrictReflectMethod, n.Sel.Pos(), "method value through reflect is not allowed")
+				}
+			}
+
+			return true
+		})
+	}
+
+	if len(violations) > 0 {
+		return &ViolationError{Violations: violations}
+	}
+
+	return nil
+}
+
+// hasMethodSelector returns true when any of the sources has selector named Method or MethodByName
+func hasMethodSelector(sources []*source) bool {
+	found := false
+	for _, s := range sources {
+		ast.Inspect(s.node, func(node ast.Node) bool {
+			if n, ok := node.(*ast.SelectorExpr); ok && (n.Sel.Name == "Method" || n.Sel.Name == "MethodByName") {
+				found = true
+			}
+			return !found
+		})
+	}
+
+	return found
+}
+
+// reflectMethodPositions returns positions of the Method and MethodByName selections that are not proven safe. Selections that cannot be type checked are not safe
+func (e *Eek) reflectMethodPositions() (map[string]bool, error) {
+	checked, err := e.typeCheckSources()
+	if err != nil {
+		return nil, err
+	}
+
+	positions := make(map[string]bool)
+	checked.inspect(func(node ast.Node) {
+		n, ok := node.(*ast.SelectorExpr)
+		if !ok || (n.Sel.Name != "Method" && n.Sel.Name != "MethodByName") {
+			return
+		}
+
+		// qualified identifier, e.g. pkg.Method
+		if ident, ok := n.X.(*ast.Ident); ok {
+			if _, ok := checked.info.Uses[ident].(*types.PkgName); ok {
+				return
+			}
+		}
+
+		if selection, ok := checked.info.Selections[n]; !ok || !isSafeMethodSelection(selection) {
+			positions[checked.fset.Position(n.Sel.Pos()).String()] = true
+		}
+	})
+
+	return positions, nil
+}
+
+// isSafeMethodSelection returns true when the selected method cannot be the one of reflect.Value. Methods of concrete types other than reflect.Value and methods of reflect.Type are safe, methods of other interfaces are not
+func isSafeMethodSelection(selection *types.Selection) bool {
+	fn, ok := selection.Obj().(*types.Func)
+	if !ok {
+		return true
+	}
+
+	recv := fn.Type().(*types.Signature).Recv().Type()
+	if pointer, ok := recv.(*types.Pointer); ok {
+		recv = pointer.Elem()
+	}
+	if named, ok := recv.(*types.Named); ok && named.Obj().Pkg() != nil && named.Obj().Pkg().Path() == "reflect" {
+		return named.Obj().Name() == "Type"
+	}
+
+	return !types.IsInterface(recv)
+}
@@ -0,0 +1,145 @@
+package eek
+
+import (
+	"reflect"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRestriction(t *testing.T) {
+	Convey("Error constructs are restricted", t, func() {
+		obj := New("restriction")
+		obj.EnableRestriction(RestrictGoStatement, RestrictChannel, RestrictDefer, RestrictGoto, RestrictInfiniteLoop)
+		obj.DefineFunction(Func{Name: "Spin", BodyFunction: `func() { for {} }`})
+		obj.PrepareEvaluation(`
+			ch := make(chan int, 1)
+			go func() { ch <- 1 }()
+			defer func() { recover() }()
+			goto end
+		end:
+			return <-ch
+		`)
+
+		err := obj.Build()
+		So(err, ShouldBeError)
+
+		violationErr, ok := err.(*ViolationError)
+		So(ok, ShouldBeTrue)
+
+		messages := make([]string, 0)
+		for _, each := range violationErr.Violations {
+			messages = append(messages, each.String())
+		}
+		So(messages, ShouldResemble, []string{
+			"Evaluate:1:12: channel type is not allowed",
+			"Evaluate:2:4: go statement is not allowed",
+			"Evaluate:2:19: channel send is not allowed",
+			"Evaluate:3:4: defer statement is not allowed",
+			"Evaluate:3:19: recover is not allowed",
+			"Evaluate:4:4: goto statement is not allowed",
+			"Evaluate:6:11: channel receive is not allowed",
+			"Spin:1:10: for loop without condition is not allowed",
+		})
+
+		Convey("Disable restrictions", func() {
+			obj.DisableRestriction(RestrictChannel, RestrictGoStatement, RestrictDefer, RestrictGoto)
+
+			err := obj.Build()
+			So(err, ShouldBeError)
+			So(err.Error(), ShouldEqual, "Spin:1:10: for loop without condition is not allowed")
+		})
+	})
+
+	Convey("Error reflect method value is restricted", t, func() {
+		obj := New("restriction")
+		obj.EnableRestriction(RestrictReflectMethod, RestrictInit)
+		obj.ImportPackage("reflect")
+		obj.DefineFunction(Func{Name: "init", BodyFunction: `func() {}`})
+		obj.PrepareEvaluation(`return reflect.ValueOf(1).MethodByName("String")`)
+
+		err := obj.Build()
+		So(err, ShouldBeError)
+		So(err.Error(), ShouldEqual, "Evaluate:1:27: method value through reflect is not allowed; init:1:1: init function is not allowed")
+	})
+
+	Convey("Reflect method of reflect.Type is not restricted", t, func() {
+		obj := New("restriction_reflect_type")
+		obj.EnableRestriction(RestrictReflectMethod)
+		obj.ImportPackage("reflect")
+		obj.PrepareEvaluation(`
+			method, _ := reflect.TypeOf(1).MethodByName("String")
+			return method.Name
+		`)
+
+		err := obj.Build()
+		So(err, ShouldBeNil)
+
+		obj.PrepareEvaluation(`
+			value := reflect.Indirect(reflect.ValueOf(1))
+			return value.Method(0)
+		`)
+		err = obj.Build()
+		So(err, ShouldBeError)
+		So(err.Error(), ShouldEqual, "Evaluate:2:17: method value through reflect is not allowed")
+	})
+
+	Convey("Error reflect method value obtained indirectly is restricted", t, func() {
+		obj := New("restriction_reflect_indirect")
+		obj.EnableRestriction(RestrictReflectMethod)
+		obj.ImportPackage("reflect")
+		obj.RegisterHostFunc("Lookup", func() reflect.Value { return reflect.ValueOf(1) })
+		obj.PrepareEvaluation(`
+			get := func() reflect.Value { return reflect.ValueOf(1) }
+			var x interface{} = reflect.ValueOf(1)
+			holder := struct{ V reflect.Value }{reflect.ValueOf(1)}
+			values := map[string]reflect.Value{"a": reflect.ValueOf(1)}
+			_, _, _ = x.(reflect.Value).Method(0), holder.V.Method(0), values["a"].Method(0)
+			_ = Lookup().Method(0)
+			return get().Method(0)
+		`)
+
+		err := obj.Build()
+		So(err, ShouldBeError)
+		So(err.Error(), ShouldEqual, "Evaluate:5:32: method value through reflect is not allowed; "+
+			"Evaluate:5:52: method value through reflect is not allowed; "+
+			"Evaluate:5:75: method value through reflect is not allowed; "+
+			"Evaluate:6:17: method value through reflect is not allowed; "+
+			"Evaluate:7:17: method value through reflect is not allowed")
+	})
+
+	Convey("Error infinite loop with true condition is restricted", t, func() {
+		obj := New("restriction")
+		obj.EnableRestriction(RestrictInfiniteLoop)
+		obj.PrepareEvaluation(`
+			for true {
+			}
+			return 1
+		`)
+
+		err := obj.Build()
+		So(err, ShouldBeError)
+		So(err.Error(), ShouldEqual, "Evaluate:1:1: for loop without condition is not allowed")
+	})
+
+	Convey("Error declarations injected outside of the formula", t, func() {
+		obj := New("restriction")
+		obj.EnableRestriction(RestrictGoStatement, RestrictInit, RestrictInfiniteLoop)
+		obj.PrepareEvaluation("return 1 }\nfunc init() { go func(){ for {} }() }\nfunc _() {")
+
+		err := obj.Build()
+		So(err, ShouldBeError)
+		So(err.Error(), ShouldEqual, "evaluation formula Evaluate is invalid. Evaluate: formula must be a function body, declarations outside of it are not allowed")
+	})
+
+	Convey("Error declarations injected outside of the defined function", t, func() {
+		obj := New("restriction")
+		obj.EnableRestriction(RestrictGoStatement, RestrictInit)
+		obj.DefineFunction(Func{Name: "One", BodyFunction: "func() int { return 1 }\nfunc init() { go func(){}() }"})
+		obj.PrepareEvaluation(`return One()`)
+
+		err := obj.Build()
+		So(err, ShouldBeError)
+		So(err.Error(), ShouldEqual, "defined function One has invalid body. One: function body must be a single function literal")
+	})
+}
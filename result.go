@@ -0,0 +1,273 @@
+package eek
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+var (
+	typeJSONUnmarshaler = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+)
+
+// FormulaError is an error returned by the evaluation formula itself, available only when UseErrorReturn is enabled
+type FormulaError struct {
+	Err error
+}
+
+func (e *FormulaError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the error returned by the evaluation formula
+func (e *FormulaError) Unwrap() error {
+	return e.Err
+}
+
+// EvaluateFloat64 execute using particular data, the result must be a float64
+func (e *Eek) EvaluateFloat64(data ExecVar) (float64, error) {
+	result, err := e.Evaluate(data)
+	if !e.isResultAvailable(err) {
+		return 0, err
+	}
+
+	value, ok := result.(float64)
+	if !ok {
+		return 0, resultTypeError(result, "float64")
+	}
+
+	return value, err
+}
+
+// EvaluateInt execute using particular data, the result must be an int
+func (e *Eek) EvaluateInt(data ExecVar) (int, error) {
+	result, err := e.Evaluate(data)
+	if !e.isResultAvailable(err) {
+		return 0, err
+	}
+
+	value, ok := result.(int)
+	if !ok {
+		return 0, resultTypeError(result, "int")
+	}
+
+	return value, err
+}
+
+// EvaluateString execute using particular data, the result must be a string
+func (e *Eek) EvaluateString(data ExecVar) (string, error) {
+	result, err := e.Evaluate(data)
+	if !e.isResultAvailable(err) {
+		return "", err
+	}
+
+	value, ok := result.(string)
+	if !ok {
+		return "", resultTypeError(result, "string")
+	}
+
+	return value, err
+}
+
+// EvaluateBool execute using particular data, the result must be a bool
+func (e *Eek) EvaluateBool(data ExecVar) (bool, error) {
+	result, err := e.Evaluate(data)
+	if !e.isResultAvailable(err) {
+		return false, err
+	}
+
+	value, ok := result.(bool)
+	if !ok {
+		return false, resultTypeError(result, "bool")
+	}
+
+	return value, err
+}
+
+// ConversionError is returned by EvaluateInto when the result cannot be converted into the target value
+type ConversionError struct {
+	// Path is the path of the offending value inside the target, e.g. result.Items[0].Price
+	Path string
+	Err  error
+}
+
+func (e *ConversionError) Error() string {
+	return fmt.Sprintf("cannot convert %s: %s", e.Path, e.Err.Error())
+}
+
+// Unwrap returns the underlying conversion error
+func (e *ConversionError) Unwrap() error {
+	return e.Err
+}
+
+// EvaluateInto execute using particular data, then store the result into the value pointed by out. The result is assigned when it is assignable, otherwise numbers are converted without loss, maps and structs are decoded field by field, and types that implement json.Unmarshaler are decoded from the JSON encoding of the result
+func (e *Eek) EvaluateInto(data ExecVar, out interface{}) error {
+	target := reflect.ValueOf(out)
+	if target.Kind() != reflect.Ptr || target.IsNil() {
+		return fmt.Errorf("out must be a non-nil pointer, got %T", out)
+	}
+
+	result, err := e.Evaluate(data)
+	if !e.isResultAvailable(err) {
+		return err
+	}
+
+	if errConvert := convertInto(reflect.ValueOf(result), target.Elem(), "result"); errConvert != nil {
+		return errConvert
+	}
+
+	return err
+}
+
+// convertInto store value into target, path is used to name the offending value on error
+func convertInto(value reflect.Value, target reflect.Value, path string) error {
+	if value.Kind() == reflect.Interface && !value.IsNil() {
+		value = value.Elem()
+	}
+
+	if !value.IsValid() || (isNilableKind(value.Kind()) && value.IsNil()) {
+		target.Set(reflect.Zero(target.Type()))
+		return nil
+	}
+
+	if value.Type().AssignableTo(target.Type()) {
+		target.Set(value)
+		return nil
+	}
+
+	if reflect.PtrTo(target.Type()).Implements(typeJSONUnmarshaler) {
+		encoded, err := json.Marshal(value.Interface())
+		if err == nil {
+			err = target.Addr().Interface().(json.Unmarshaler).UnmarshalJSON(encoded)
+		}
+		if err != nil {
+			return &ConversionError{Path: path, Err: err}
+		}
+		return nil
+	}
+
+	switch {
+	case target.Kind() == reflect.Ptr:
+		elem := reflect.New(target.Type().Elem())
+		if err := convertInto(value, elem.Elem(), path); err != nil {
+			return err
+		}
+		target.Set(elem)
+		return nil
+	case isNumberKind(value.Kind()) && isNumberKind(target.Kind()):
+		number, err := coerceNumber(value, target.Type())
+		if err != nil {
+			return &ConversionError{Path: path, Err: err}
+		}
+		target.Set(number)
+		return nil
+	case value.Kind() == target.Kind() && value.Type().ConvertibleTo(target.Type()):
+		// named types of the same underlying type, e.g. types defined in the formula
+		target.Set(value.Convert(target.Type()))
+		return nil
+	case (value.Kind() == reflect.Slice || value.Kind() == reflect.Array) && target.Kind() == reflect.Slice:
+		result := reflect.MakeSlice(target.Type(), value.Len(), value.Len())
+		for i := 0; i < value.Len(); i++ {
+			if err := convertInto(value.Index(i), result.Index(i), fmt.Sprintf("%s[%d]", path, i)); err != nil {
+				return err
+			}
+		}
+		target.Set(result)
+		return nil
+	case value.Kind() == reflect.Map && target.Kind() == reflect.Map:
+		result := reflect.MakeMapWithSize(target.Type(), value.Len())
+		for _, key := range value.MapKeys() {
+			elemPath := fmt.Sprintf("%s[%v]", path, key.Interface())
+			targetKey := reflect.New(target.Type().Key()).Elem()
+			if err := convertInto(key, targetKey, elemPath); err != nil {
+				return err
+			}
+			targetElem := reflect.New(target.Type().Elem()).Elem()
+			if err := convertInto(value.MapIndex(key), targetElem, elemPath); err != nil {
+				return err
+			}
+			result.SetMapIndex(targetKey, targetElem)
+		}
+		target.Set(result)
+		return nil
+	case value.Kind() == reflect.Map && value.Type().Key().Kind() == reflect.String && target.Kind() == reflect.Struct:
+		for i := 0; i < target.NumField(); i++ {
+			field := target.Type().Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+
+			if elem := mapIndexOf(value, fieldKeyOf(field)); elem.IsValid() {
+				if err := convertInto(elem, target.Field(i), path+"."+field.Name); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	case value.Kind() == reflect.Struct && target.Kind() == reflect.Struct:
+		for i := 0; i < target.NumField(); i++ {
+			field := target.Type().Field(i)
+			if field.PkgPath != "" {
+				continue
+			}
+
+			if elem := value.FieldByName(field.Name); elem.IsValid() {
+				if err := convertInto(elem, target.Field(i), path+"."+field.Name); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	return &ConversionError{Path: path, Err: fmt.Errorf("value %v (type %s) is not convertible to type %s", value.Interface(), value.Type(), target.Type())}
+}
+
+// fieldKeyOf returns the map key of struct field, which is the name of json tag, or the field name
+func fieldKeyOf(field reflect.StructField) string {
+	if name := strings.Split(field.Tag.Get("json"), ",")[0]; name != "" && name != "-" {
+		return name
+	}
+
+	return field.Name
+}
+
+// mapIndexOf returns map element of the key. Like encoding/json, exact match is preferred over case-insensitive match
+func mapIndexOf(m reflect.Value, key string) reflect.Value {
+	keyValue := reflect.ValueOf(key).Convert(m.Type().Key())
+	if elem := m.MapIndex(keyValue); elem.IsValid() {
+		return elem
+	}
+
+	for _, each := range m.MapKeys() {
+		if strings.EqualFold(each.String(), key) {
+			return m.MapIndex(each)
+		}
+	}
+
+	return reflect.Value{}
+}
+
+func isNilableKind(kind reflect.Kind) bool {
+	switch kind {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface, reflect.Func, reflect.Chan:
+		return true
+	}
+
+	return false
+}
+
+// isResultAvailable returns true when the evaluation is executed, which is when there is no error, or when the unknown variables are collected
+func (e *Eek) isResultAvailable(err error) bool {
+	if err == nil {
+		return true
+	}
+
+	_, ok := err.(*UnknownVariableError)
+	return ok && e.UnknownVariablePolicy == UnknownVariableCollect
+}
+
+func resultTypeError(result interface{}, expectedType string) error {
+	return fmt.Errorf("result of evaluation is %v (type %T), expected type %s", result, result, expectedType)
+}
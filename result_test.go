@@ -0,0 +1,162 @@
+package eek
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestReturnType(t *testing.T) {
+	Convey("Create Eek object with return type", t, func() {
+		obj := New("return type")
+		obj.DefineVariable(Var{Name: "A", Type: "float64"})
+		obj.SetReturnType("float64")
+		obj.PrepareEvaluation(`return A * 2`)
+
+		Convey("Build operation", func() {
+			err := obj.Build()
+			So(err, ShouldBeNil)
+
+			Convey("Test typed exec", func() {
+				output, err := obj.EvaluateFloat64(ExecVar{"A": 1.25})
+				So(err, ShouldBeNil)
+				So(output, ShouldEqual, 2.5)
+			})
+
+			Convey("Test typed exec with other type", func() {
+				_, err := obj.EvaluateInt(ExecVar{"A": 1.25})
+				So(err, ShouldBeError)
+				So(err.Error(), ShouldEqual, "result of evaluation is 2.5 (type float64), expected type int")
+			})
+		})
+	})
+
+	Convey("Error formula does not match the return type", t, func() {
+		obj := New("return type mismatch")
+		obj.SetReturnType("float64")
+		obj.PrepareEvaluation(`return "text"`)
+		err := obj.Build()
+		So(err, ShouldBeError)
+	})
+}
+
+func TestErrorReturn(t *testing.T) {
+	Convey("Create Eek object with error return", t, func() {
+		obj := New("error return")
+		obj.UseErrorReturn = true
+		obj.ImportPackage("errors")
+		obj.DefineVariable(Var{Name: "Amount", Type: "float64"})
+		obj.SetReturnType("float64")
+		obj.PrepareEvaluation(`
+			if Amount < 0 {
+				return 0, errors.New("amount cannot be negative")
+			}
+			return Amount * 1.1, nil
+		`)
+
+		Convey("Build operation", func() {
+			err := obj.Build()
+			So(err, ShouldBeNil)
+
+			Convey("Test exec", func() {
+				output, err := obj.EvaluateFloat64(ExecVar{"Amount": 10.0})
+				So(err, ShouldBeNil)
+				So(output, ShouldAlmostEqual, 11)
+			})
+
+			Convey("Test exec with formula error", func() {
+				output, err := obj.Evaluate(ExecVar{"Amount": -1.0})
+				So(output, ShouldBeNil)
+				So(err, ShouldBeError)
+				So(err.Error(), ShouldEqual, "amount cannot be negative")
+
+				formulaErr, ok := err.(*FormulaError)
+				So(ok, ShouldBeTrue)
+				So(formulaErr.Unwrap().Error(), ShouldEqual, "amount cannot be negative")
+			})
+
+			Convey("Test exec with bind error", func() {
+				_, err := obj.Evaluate(ExecVar{"Amount": -1})
+				So(err, ShouldBeError)
+
+				_, ok := err.(*FormulaError)
+				So(ok, ShouldBeFalse)
+			})
+		})
+	})
+}
+
+type cents int64
+
+func (c *cents) UnmarshalJSON(data []byte) error {
+	var value float64
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+
+	*c = cents(math.Round(value * 100))
+	return nil
+}
+
+type invoice struct {
+	Total cents
+	Lines []invoiceLine `json:"lines"`
+	Note  *string
+}
+
+type invoiceLine struct {
+	Name string
+	Qty  uint8
+}
+
+func TestEvaluateInto(t *testing.T) {
+	Convey("Create Eek object with structured result", t, func() {
+		obj := New("evaluation into")
+		obj.DefineVariable(Var{Name: "Qty", Type: "interface{}"})
+		obj.PrepareEvaluation(`
+			return map[string]interface{}{
+				"total": 12.5,
+				"lines": []map[string]interface{}{{"name": "a", "qty": Qty}},
+				"note":  "paid",
+			}
+		`)
+
+		Convey("Build operation", func() {
+			err := obj.Build()
+			So(err, ShouldBeNil)
+
+			Convey("Test exec into struct", func() {
+				out := invoice{}
+				err := obj.EvaluateInto(ExecVar{"Qty": 2.0}, &out)
+				So(err, ShouldBeNil)
+				So(out.Total, ShouldEqual, cents(1250))
+				So(out.Lines, ShouldResemble, []invoiceLine{{Name: "a", Qty: 2}})
+				So(*out.Note, ShouldEqual, "paid")
+			})
+
+			Convey("Test exec into map", func() {
+				out := map[string]interface{}{}
+				err := obj.EvaluateInto(ExecVar{"Qty": 2.0}, &out)
+				So(err, ShouldBeNil)
+				So(out["total"], ShouldEqual, 12.5)
+			})
+
+			Convey("Test exec with impossible conversion", func() {
+				out := invoice{}
+				err := obj.EvaluateInto(ExecVar{"Qty": 300}, &out)
+				So(err, ShouldBeError)
+				So(err.Error(), ShouldEqual, "cannot convert result.Lines[0].Qty: value 300 cannot be represented by type uint8")
+
+				err = obj.EvaluateInto(ExecVar{"Qty": "x"}, &out)
+				So(err, ShouldBeError)
+				So(err.Error(), ShouldEqual, "cannot convert result.Lines[0].Qty: value x (type string) is not convertible to type uint8")
+
+				err = obj.EvaluateInto(ExecVar{"Qty": 2}, out)
+				So(err, ShouldBeError)
+				So(err.Error(), ShouldEqual, "out must be a non-nil pointer, got eek.invoice")
+			})
+		})
+	})
+}
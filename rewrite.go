@@ -0,0 +1,109 @@
+package eek
+
+import (
+	"sort"
+)
+
+// replacement is reflect to a piece of user written code that is replaced before build
+type replacement struct {
+	offset int
+	length int
+	text   string
+}
+
+// rewrittenSources holds the code of formulas and function bodies after the replacements are applied
+type rewrittenSources struct {
+	formulas  map[string]string
+	functions map[string]string
+}
+
+// formula returns the rewritten code of an evaluation formula, or the original code when nothing is replaced
+func (r *rewrittenSources) formula(name, code string) string {
+	if rewritten, ok := r.formulas[name]; ok {
+		return rewritten
+	}
+
+	return code
+}
+
+// function returns the rewritten body of a defined function, or the original body when nothing is replaced
+func (r *rewrittenSources) function(name, code string) string {
+	if rewritten, ok := r.functions[name]; ok {
+		return rewritten
+	}
+
+	return code
+}
+
+// replacementsOf collect the replacements needed by the enabled features on a particular source
+func (e *Eek) replacementsOf(s *source) ([]replacement, []Violation) {
+	replacements := make([]replacement, 0)
+	violations := make([]Violation, 0)
+
+	if e.Deterministic {
+		each, violation := e.deterministicReplacements(s)
+		replacements = append(replacements, each...)
+		violations = append(violations, violation...)
+	}
+
+	if lazyVariables := e.lazyVariables(); len(lazyVariables) > 0 {
+		each, violation := e.lazyReplacements(s, lazyVariables)
+		replacements = append(replacements, each...)
+		violations = append(violations, violation...)
+	}
+
+	return replacements, violations
+}
+
+// rewriteSources apply the replacements needed by the enabled features into every formula and defined function
+func (e *Eek) rewriteSources() (*rewrittenSources, error) {
+	rewritten := &rewrittenSources{
+		formulas:  make(map[string]string),
+		functions: make(map[string]string),
+	}
+
+	if !e.Deterministic && len(e.lazyVariables()) == 0 {
+		return rewritten, nil
+	}
+
+	sources, err := e.parseSources()
+	if err != nil {
+		return nil, err
+	}
+
+	violations := make([]Violation, 0)
+	for _, each := range sources {
+		replacements, violation := e.replacementsOf(each)
+		violations = append(violations, violation...)
+		if len(replacements) == 0 {
+			continue
+		}
+
+		if each.isFormula {
+			rewritten.formulas[each.name] = applyReplacements(each.code, replacements)
+		} else {
+			rewritten.functions[each.name] = applyReplacements(each.code, replacements)
+		}
+	}
+
+	if len(violations) > 0 {
+		return nil, &ViolationError{Violations: violations}
+	}
+
+	return rewritten, nil
+}
+
+func applyReplacements(code string, replacements []replacement) string {
+	sort.Slice(replacements, func(i, j int) bool {
+		return replacements[i].offset < replacements[j].offset
+	})
+
+	result := ""
+	cursor := 0
+	for _, each := range replacements {
+		result += code[cursor:each.offset] + each.text
+		cursor = each.offset + each.length
+	}
+
+	return result + code[cursor:]
+}
@@ -0,0 +1,216 @@
+package eek
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const (
+	// schemaVersion is the JSON Schema draft of the document returned by Schema
+	schemaVersion = "http://json-schema.org/draft-07/schema#"
+
+	// schemaOutputDefinition is the definition name of the return type. Defined types must be exported, so it never collides with them
+	schemaOutputDefinition = "output"
+)
+
+// schemaObject is a single JSON Schema object
+type schemaObject map[string]interface{}
+
+// Schema returns JSON Schema document of the variables, which is the input of evaluation, following the type, default value, and constraint of each variable. Lazy variables are never required. Defined types are put into the definitions, along with the return type or the outputs as definition "output"
+func (e *Eek) Schema() ([]byte, error) {
+	properties := make(schemaObject)
+	required := make([]string, 0)
+	for _, each := range e.variables {
+		property, err := e.variableSchema(each)
+		if err != nil {
+			return nil, err
+		}
+		properties[each.Name] = property
+
+		if each.Constraint != nil && each.Constraint.NotNil && each.DefaultValue == nil && each.Source == nil {
+			required = append(required, each.Name)
+		}
+	}
+
+	definitions := make(schemaObject)
+	for _, each := range e.types {
+		definition, err := e.typeSchema(each.Definition)
+		if err != nil {
+			return nil, fmt.Errorf("cannot describe type %s. %s", each.Name, err.Error())
+		}
+		definitions[each.Name] = definition
+	}
+
+	if len(e.outputs) > 0 {
+		outputs := make(schemaObject)
+		names := make([]string, 0)
+		for _, each := range e.outputs {
+			output, err := e.typeSchema(each.Type)
+			if err != nil {
+				return nil, fmt.Errorf("cannot describe output %s. %s", each.Name, err.Error())
+			}
+			outputs[each.Name] = output
+			names = append(names, each.Name)
+		}
+		definitions[schemaOutputDefinition] = schemaObject{"type": "object", "properties": outputs, "required": names}
+	} else if e.returnType != "" {
+		definition, err := e.typeSchema(e.returnType)
+		if err != nil {
+			return nil, fmt.Errorf("cannot describe return type. %s", err.Error())
+		}
+		definitions[schemaOutputDefinition] = definition
+	}
+
+	document := schemaObject{
+		"$schema":    schemaVersion,
+		"title":      e.name,
+		"type":       "object",
+		"properties": properties,
+	}
+	if len(required) > 0 {
+		document["required"] = required
+	}
+	if len(definitions) > 0 {
+		document["definitions"] = definitions
+	}
+
+	return json.MarshalIndent(document, "", "  ")
+}
+
+// variableSchema returns schema of the variable type, along with its default value and constraint
+func (e *Eek) variableSchema(variable Var) (schemaObject, error) {
+	schema, err := e.typeSchema(variable.Type)
+	if err != nil {
+		return nil, fmt.Errorf("cannot describe variable %s. %s", variable.Name, err.Error())
+	}
+
+	// keywords next to $ref are ignored, so the reference is wrapped
+	if _, ok := schema["$ref"]; ok && (variable.DefaultValue != nil || variable.Constraint != nil) {
+		schema = schemaObject{"allOf": []schemaObject{schema}}
+	}
+
+	if variable.DefaultValue != nil {
+		schema["default"] = variable.DefaultValue
+	}
+
+	if c := variable.Constraint; c != nil {
+		if c.Min != nil {
+			schema["minimum"] = c.Min
+		}
+		if c.Max != nil {
+			schema["maximum"] = c.Max
+		}
+		if len(c.Enum) > 0 {
+			schema["enum"] = c.Enum
+		}
+		if c.Pattern != "" {
+			schema["pattern"] = c.Pattern
+		}
+		if c.MaxLength > 0 {
+			switch schema["type"] {
+			case "array":
+				schema["maxItems"] = c.MaxLength
+			case "object":
+				schema["maxProperties"] = c.MaxLength
+			default:
+				schema["maxLength"] = c.MaxLength
+			}
+		}
+	}
+
+	return schema, nil
+}
+
+// typeSchema returns schema of go type expression
+func (e *Eek) typeSchema(typ string) (schemaObject, error) {
+	expr, err := parser.ParseExpr(typ)
+	if err != nil || !isTypeNode(expr) {
+		return nil, fmt.Errorf("invalid type %q", typ)
+	}
+
+	return e.typeNodeSchema(expr), nil
+}
+
+func (e *Eek) typeNodeSchema(expr ast.Expr) schemaObject {
+	switch n := expr.(type) {
+	case *ast.Ident:
+		switch n.Name {
+		case "bool":
+			return schemaObject{"type": "boolean"}
+		case "int", "int8", "int16", "int32", "int64", "rune":
+			return schemaObject{"type": "integer"}
+		case "uint", "uint8", "uint16", "uint32", "uint64", "uintptr", "byte":
+			return schemaObject{"type": "integer", "minimum": 0}
+		case "float32", "float64":
+			return schemaObject{"type": "number"}
+		case "string":
+			return schemaObject{"type": "string"}
+		}
+
+		for _, each := range e.types {
+			if each.Name == n.Name {
+				return schemaObject{"$ref": "#/definitions/" + n.Name}
+			}
+		}
+	case *ast.SelectorExpr:
+		switch fmt.Sprintf("%s.%s", n.X.(*ast.Ident).Name, n.Sel.Name) {
+		case "time.Time":
+			return schemaObject{"type": "string", "format": "date-time"}
+		case "time.Duration":
+			return schemaObject{"type": "integer"}
+		case "json.Number":
+			return schemaObject{"type": "number"}
+		}
+	case *ast.ParenExpr:
+		return e.typeNodeSchema(n.X)
+	case *ast.StarExpr:
+		return e.typeNodeSchema(n.X)
+	case *ast.ArrayType:
+		// encoding/json encodes []byte as base64 string
+		if ident, ok := n.Elt.(*ast.Ident); ok && n.Len == nil && (ident.Name == "byte" || ident.Name == "uint8") {
+			return schemaObject{"type": "string", "contentEncoding": "base64"}
+		}
+
+		schema := schemaObject{"type": "array", "items": e.typeNodeSchema(n.Elt)}
+		if lit, ok := n.Len.(*ast.BasicLit); ok {
+			if length, err := strconv.Atoi(lit.Value); err == nil {
+				schema["minItems"] = length
+				schema["maxItems"] = length
+			}
+		}
+		return schema
+	case *ast.MapType:
+		return schemaObject{"type": "object", "additionalProperties": e.typeNodeSchema(n.Value)}
+	case *ast.StructType:
+		properties := make(schemaObject)
+		for _, field := range n.Fields.List {
+			for _, name := range field.Names {
+				if !isExportedName(name.Name) {
+					continue
+				}
+
+				key := name.Name
+				if field.Tag != nil {
+					if tag, err := strconv.Unquote(field.Tag.Value); err == nil {
+						jsonName := strings.Split(reflect.StructTag(tag).Get("json"), ",")[0]
+						if jsonName == "-" {
+							continue
+						} else if jsonName != "" {
+							key = jsonName
+						}
+					}
+				}
+				properties[key] = e.typeNodeSchema(field.Type)
+			}
+		}
+		return schemaObject{"type": "object", "properties": properties}
+	}
+
+	// interface, function, channel, and unknown types accept any value
+	return schemaObject{}
+}
@@ -0,0 +1,84 @@
+package eek
+
+import (
+	"encoding/json"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestSchema(t *testing.T) {
+	Convey("Create Eek object with variables", t, func() {
+		obj := New("pricing")
+		obj.ImportPackage("time")
+		obj.DefineType("Tier", "int")
+		obj.DefineType("Item", "struct {\n Name string `json:\"name\"`\n Price float64\n secret int\n}")
+		obj.DefineVariable(Var{Name: "Price", Type: "float64", Constraint: &Constraint{Min: 0, Max: 100, NotNil: true}})
+		obj.DefineVariable(Var{Name: "Code", Type: "string", DefaultValue: "A", Constraint: &Constraint{Pattern: "^[A-Z]+$", MaxLength: 3, NotNil: true}})
+		obj.DefineVariable(Var{Name: "Level", Type: "Tier", DefaultValue: 1, Constraint: &Constraint{Enum: []interface{}{1, 2}}})
+		obj.DefineVariable(Var{Name: "Items", Type: "[]Item", Constraint: &Constraint{MaxLength: 10}})
+		obj.DefineVariable(Var{Name: "Since", Type: "*time.Time"})
+		obj.DefineVariable(Var{Name: "Extra", Type: "map[string]interface{}"})
+		obj.SetReturnType("map[string]float64")
+
+		Convey("Export schema", func() {
+			output, err := obj.Schema()
+			So(err, ShouldBeNil)
+
+			schema := make(map[string]interface{})
+			So(json.Unmarshal(output, &schema), ShouldBeNil)
+			So(schema["$schema"], ShouldEqual, "http://json-schema.org/draft-07/schema#")
+			So(schema["title"], ShouldEqual, "pricing")
+			So(schema["required"], ShouldResemble, []interface{}{"Price"})
+
+			properties := schema["properties"].(map[string]interface{})
+			So(properties["Price"], ShouldResemble, map[string]interface{}{"type": "number", "minimum": 0.0, "maximum": 100.0})
+			So(properties["Code"], ShouldResemble, map[string]interface{}{"type": "string", "default": "A", "pattern": "^[A-Z]+$", "maxLength": 3.0})
+			So(properties["Level"], ShouldResemble, map[string]interface{}{
+				"allOf":   []interface{}{map[string]interface{}{"$ref": "#/definitions/Tier"}},
+				"default": 1.0,
+				"enum":    []interface{}{1.0, 2.0},
+			})
+			So(properties["Items"], ShouldResemble, map[string]interface{}{
+				"type":     "array",
+				"items":    map[string]interface{}{"$ref": "#/definitions/Item"},
+				"maxItems": 10.0,
+			})
+			So(properties["Since"], ShouldResemble, map[string]interface{}{"type": "string", "format": "date-time"})
+			So(properties["Extra"], ShouldResemble, map[string]interface{}{"type": "object", "additionalProperties": map[string]interface{}{}})
+
+			definitions := schema["definitions"].(map[string]interface{})
+			So(definitions["Tier"], ShouldResemble, map[string]interface{}{"type": "integer"})
+			So(definitions["Item"], ShouldResemble, map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"name":  map[string]interface{}{"type": "string"},
+					"Price": map[string]interface{}{"type": "number"},
+				},
+			})
+			So(definitions["output"], ShouldResemble, map[string]interface{}{"type": "object", "additionalProperties": map[string]interface{}{"type": "number"}})
+		})
+
+		Convey("Export schema with outputs", func() {
+			obj.SetReturnType("")
+			obj.DefineOutput(Var{Name: "Score", Type: "float64"})
+			output, err := obj.Schema()
+			So(err, ShouldBeNil)
+
+			schema := make(map[string]interface{})
+			So(json.Unmarshal(output, &schema), ShouldBeNil)
+			So(schema["definitions"].(map[string]interface{})["output"], ShouldResemble, map[string]interface{}{
+				"type":       "object",
+				"properties": map[string]interface{}{"Score": map[string]interface{}{"type": "number"}},
+				"required":   []interface{}{"Score"},
+			})
+		})
+
+		Convey("Export schema with invalid type", func() {
+			obj.DefineVariable(Var{Name: "Broken", Type: "[]"})
+			_, err := obj.Schema()
+			So(err, ShouldBeError)
+			So(err.Error(), ShouldEqual, `cannot describe variable Broken. invalid type "[]"`)
+		})
+	})
+}
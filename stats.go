@@ -0,0 +1,113 @@
+package eek
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"runtime"
+	"runtime/pprof"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// goroutineSettleTimeout is how long goroutines started by the evaluation are waited to finish before they are counted as leaked
+	goroutineSettleTimeout = 50 * time.Millisecond
+
+	// evaluationLabel is the pprof label set on the goroutine of the evaluation, goroutines started by the evaluation inherit it
+	evaluationLabel = "eek_evaluation"
+)
+
+// evaluationCounter is used to give every measured evaluation its own label value
+var evaluationCounter uint64
+
+// EvalStats is reflect to the resource usage of a single evaluation. Allocation counters are process wide, allocations of other goroutines running at the same time are counted as well. Goroutines are counted per evaluation, goroutines of the host are not counted
+type EvalStats struct {
+	WallTime       time.Duration
+	Allocs         uint64
+	BytesAllocated uint64
+
+	// GoroutineDelta is the number of goroutines started by the evaluation that are still running after it
+	GoroutineDelta int
+
+	// GoroutineLeak is true when the evaluation leaves goroutines behind
+	GoroutineLeak bool
+}
+
+// GoroutineLeakError is returned on evaluation when RejectGoroutineLeak is enabled and the formula leaves goroutines behind
+type GoroutineLeakError struct {
+	Count int
+}
+
+func (e *GoroutineLeakError) Error() string {
+	return fmt.Sprintf("evaluation leaves %d goroutine(s) behind", e.Count)
+}
+
+// evalMeter measure the resource usage of a single evaluation
+type evalMeter struct {
+	start    time.Time
+	memStats runtime.MemStats
+	label    string
+}
+
+func startEvalMeter() *evalMeter {
+	meter := new(evalMeter)
+	meter.label = strconv.FormatUint(atomic.AddUint64(&evaluationCounter, 1), 10)
+	runtime.ReadMemStats(&meter.memStats)
+	meter.start = time.Now()
+	return meter
+}
+
+// run call fn labelled by the evaluation, so goroutines started by fn are told apart from the ones of the host
+func (m *evalMeter) run(fn func()) {
+	pprof.Do(context.Background(), pprof.Labels(evaluationLabel, m.label), func(context.Context) {
+		fn()
+	})
+}
+
+// goroutines returns the number of running goroutines labelled by the evaluation
+func (m *evalMeter) goroutines() int {
+	buffer := new(bytes.Buffer)
+	if err := pprof.Lookup("goroutine").WriteTo(buffer, 1); err != nil {
+		return 0
+	}
+
+	// goroutines of the same stack and labels are grouped as "<count> @ <stack>", followed by the labels
+	label := fmt.Sprintf("%q:%q", evaluationLabel, m.label)
+	count, total := 0, 0
+	scanner := bufio.NewScanner(buffer)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if parts := strings.SplitN(line, " @ ", 2); len(parts) == 2 {
+			count, _ = strconv.Atoi(parts[0])
+		} else if strings.HasPrefix(line, "# labels: ") && strings.Contains(line, label) {
+			total += count
+		}
+	}
+
+	return total
+}
+
+func (m *evalMeter) stop() EvalStats {
+	stats := EvalStats{}
+	stats.WallTime = time.Since(m.start)
+
+	memStats := runtime.MemStats{}
+	runtime.ReadMemStats(&memStats)
+	stats.Allocs = memStats.Mallocs - m.memStats.Mallocs
+	stats.BytesAllocated = memStats.TotalAlloc - m.memStats.TotalAlloc
+
+	// goroutines started by the evaluation are given a moment to finish
+	deadline := time.Now().Add(goroutineSettleTimeout)
+	stats.GoroutineDelta = m.goroutines()
+	for stats.GoroutineDelta > 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+		stats.GoroutineDelta = m.goroutines()
+	}
+	stats.GoroutineLeak = stats.GoroutineDelta > 0
+
+	return stats
+}
@@ -0,0 +1,68 @@
+package eek
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestEvalStats(t *testing.T) {
+	Convey("Create Eek object that allocates and leaks goroutine", t, func() {
+		obj := New("stats")
+		obj.ImportPackage("time")
+		obj.DefineVariable(Var{Name: "Leak", Type: "bool"})
+		obj.PrepareEvaluation(`
+			if Leak {
+				go func() { time.Sleep(time.Second) }()
+			}
+			return len(make([]byte, 1<<20))
+		`)
+
+		Convey("Build operation", func() {
+			err := obj.Build()
+			So(err, ShouldBeNil)
+
+			Convey("Test exec with stats", func() {
+				stats := EvalStats{}
+				output, err := obj.EvaluateWith(ExecVar{"Leak": false}, EvalOptions{Stats: &stats})
+				So(err, ShouldBeNil)
+				So(output, ShouldEqual, 1<<20)
+				So(stats.WallTime, ShouldBeGreaterThan, 0)
+				So(stats.BytesAllocated, ShouldBeGreaterThanOrEqualTo, 1<<20)
+				So(stats.Allocs, ShouldBeGreaterThan, 0)
+				So(stats.GoroutineLeak, ShouldBeFalse)
+			})
+
+			Convey("Test exec leaks goroutine", func() {
+				stats := EvalStats{}
+				_, err := obj.EvaluateWith(ExecVar{"Leak": true}, EvalOptions{Stats: &stats})
+				So(err, ShouldBeNil)
+				So(stats.GoroutineDelta, ShouldEqual, 1)
+				So(stats.GoroutineLeak, ShouldBeTrue)
+			})
+
+			Convey("Test exec leaks goroutine in strict mode", func() {
+				obj.RejectGoroutineLeak = true
+				_, err := obj.Evaluate(ExecVar{"Leak": true})
+				So(err, ShouldBeError)
+				So(err.Error(), ShouldEqual, "evaluation leaves 1 goroutine(s) behind")
+			})
+		})
+	})
+
+	Convey("Count only goroutines started by the evaluation", t, func() {
+		done := make(chan bool)
+		defer close(done)
+
+		meter := startEvalMeter()
+		// goroutine of the host, started while the evaluation is running
+		go func() { <-done }()
+		meter.run(func() {
+			go func() { <-done }()
+		})
+
+		stats := meter.stop()
+		So(stats.GoroutineDelta, ShouldEqual, 1)
+		So(stats.GoroutineLeak, ShouldBeTrue)
+	})
+}
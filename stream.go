@@ -0,0 +1,63 @@
+package eek
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	// emitFunction is name of the generated function that emits a value, available when UseEmit is enabled
+	emitFunction = "Emit"
+)
+
+// ErrStopStream is returned by the callback of EvaluateStream to stop the evaluation early. EvaluateStream returns nil in that case
+var ErrStopStream = errors.New("stop stream")
+
+// streamStop is raised as panic by Emit to unwind the formula when the callback returns error, then recovered by callEntry
+type streamStop struct {
+	err error
+}
+
+// EvaluateStream execute using particular data, the values emitted by the formula are passed to fn one by one. Fn is called synchronously, so the formula does not continue until fn returns. Returning ErrStopStream stops the evaluation without error, returning other error stops the evaluation and it is returned. UseEmit must be enabled, and go statements are rejected on build then, as Emit cannot be called from other goroutine
+func (e *Eek) EvaluateStream(data ExecVar, fn func(value interface{}) error) error {
+	if !e.UseEmit {
+		return fmt.Errorf("evaluation stream requires UseEmit to be enabled")
+	}
+
+	_, err := e.EvaluateWith(data, EvalOptions{Emit: fn})
+	return err
+}
+
+// emitLayout returns the generated code of the Emit function
+func (e *Eek) emitLayout() string {
+	if !e.UseEmit {
+		return ""
+	}
+
+	return "var EekEmit func(value interface{})\nfunc Emit(value interface{}) {\n\tEekEmit(value)\n}"
+}
+
+// emitter pass the emitted values of a single evaluation to the callback. Error of the callback is raised as panic to unwind the formula, and it is recorded as well, so it is returned even when the formula recovers the panic
+type emitter struct {
+	fn  func(value interface{}) error
+	err error
+}
+
+// emit is bound as EekEmit. Once the callback returns error, every next emit unwinds the formula again without calling the callback
+func (m *emitter) emit(value interface{}) {
+	if m.err == nil {
+		m.err = m.fn(value)
+	}
+	if m.err != nil {
+		panic(&streamStop{err: m.err})
+	}
+}
+
+// failure returns whether the stream is stopped, along with the error to be returned. Stop requested by ErrStopStream is not an error
+func (m *emitter) failure() (bool, error) {
+	if m.err == nil || m.err == ErrStopStream {
+		return m.err != nil, nil
+	}
+
+	return true, m.err
+}
@@ -0,0 +1,168 @@
+package eek
+
+import (
+	"errors"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestStream(t *testing.T) {
+	Convey("Create Eek object with emit", t, func() {
+		obj := New("evaluation_with_emit")
+		obj.ImportPackage("errors")
+		obj.UseEmit = true
+		obj.UseErrorReturn = true
+		obj.DefineVariable(Var{Name: "Amount", Type: "float64"})
+		obj.DefineVariable(Var{Name: "Months", Type: "int"})
+		obj.PrepareEvaluation(`
+			if Months <= 0 {
+				return errors.New("months must be positive")
+			}
+
+			for i := 1; i <= Months; i++ {
+				Emit(map[string]interface{}{"month": i, "amount": Amount / float64(Months)})
+			}
+			return nil
+		`)
+
+		Convey("Build operation", func() {
+			err := obj.Build()
+			So(err, ShouldBeNil)
+
+			Convey("Test exec with stream", func() {
+				months := make([]interface{}, 0)
+				err := obj.EvaluateStream(ExecVar{"Amount": 300.0, "Months": 3}, func(value interface{}) error {
+					months = append(months, value.(map[string]interface{})["month"])
+					return nil
+				})
+				So(err, ShouldBeNil)
+				So(months, ShouldResemble, []interface{}{1, 2, 3})
+			})
+
+			Convey("Test exec with early stop", func() {
+				count := 0
+				err := obj.EvaluateStream(ExecVar{"Amount": 300.0, "Months": 12}, func(value interface{}) error {
+					count++
+					if count == 2 {
+						return ErrStopStream
+					}
+					return nil
+				})
+				So(err, ShouldBeNil)
+				So(count, ShouldEqual, 2)
+			})
+
+			Convey("Test exec with callback error", func() {
+				callbackErr := errors.New("storage is full")
+				err := obj.EvaluateStream(ExecVar{"Amount": 300.0, "Months": 12}, func(value interface{}) error {
+					return callbackErr
+				})
+				So(err, ShouldEqual, callbackErr)
+			})
+
+			Convey("Test exec with formula error", func() {
+				err := obj.EvaluateStream(ExecVar{"Amount": 300.0, "Months": 0}, func(value interface{}) error {
+					return nil
+				})
+				So(err, ShouldBeError)
+				So(err, ShouldHaveSameTypeAs, &FormulaError{})
+			})
+
+			Convey("Test exec collecting emitted values", func() {
+				output, err := obj.Evaluate(ExecVar{"Amount": 100.0, "Months": 2})
+				So(err, ShouldBeNil)
+				So(output, ShouldResemble, []interface{}{
+					map[string]interface{}{"month": 1, "amount": 50.0},
+					map[string]interface{}{"month": 2, "amount": 50.0},
+				})
+			})
+		})
+	})
+
+	Convey("Create Eek object with emit without error return", t, func() {
+		obj := New("evaluation_with_emit_only")
+		obj.UseEmit = true
+		obj.PrepareEvaluation(`
+			Emit(1)
+			Emit("two")
+		`)
+
+		err := obj.Build()
+		So(err, ShouldBeNil)
+
+		output, err := obj.Evaluate(ExecVar{})
+		So(err, ShouldBeNil)
+		So(output, ShouldResemble, []interface{}{1, "two"})
+	})
+
+	Convey("Create Eek object with emit recovered by the formula", t, func() {
+		obj := New("evaluation_with_recovered_emit")
+		obj.UseEmit = true
+		obj.PrepareEvaluation(`
+			for i := 0; i < 3; i++ {
+				func() {
+					defer func() { recover() }()
+					Emit(i)
+				}()
+			}
+		`)
+
+		err := obj.Build()
+		So(err, ShouldBeNil)
+
+		Convey("Test exec with callback error", func() {
+			count := 0
+			callbackErr := errors.New("consumer failed")
+			err := obj.EvaluateStream(ExecVar{}, func(value interface{}) error {
+				count++
+				return callbackErr
+			})
+			So(err, ShouldEqual, callbackErr)
+			So(count, ShouldEqual, 1)
+		})
+
+		Convey("Test exec with early stop", func() {
+			count := 0
+			err := obj.EvaluateStream(ExecVar{}, func(value interface{}) error {
+				count++
+				return ErrStopStream
+			})
+			So(err, ShouldBeNil)
+			So(count, ShouldEqual, 1)
+		})
+	})
+
+	Convey("Error stream without emit", t, func() {
+		obj := New("evaluation_without_emit")
+		obj.PrepareEvaluation(`return 1`)
+
+		err := obj.EvaluateStream(ExecVar{}, func(value interface{}) error {
+			return nil
+		})
+		So(err, ShouldBeError)
+		So(err.Error(), ShouldEqual, "evaluation stream requires UseEmit to be enabled")
+	})
+
+	Convey("Error emit from go statement", t, func() {
+		obj := New("evaluation_with_emit")
+		obj.UseEmit = true
+		obj.DisableRestriction(RestrictGoStatement)
+		obj.PrepareEvaluation(`go Emit(1)`)
+
+		err := obj.Build()
+		So(err, ShouldBeError)
+		So(err.Error(), ShouldEqual, "Evaluate:1:1: go statement is not allowed")
+	})
+
+	Convey("Error emit along with return type", t, func() {
+		obj := New("evaluation_with_emit")
+		obj.UseEmit = true
+		obj.SetReturnType("int")
+		obj.PrepareEvaluation(`Emit(1)`)
+
+		err := obj.Build()
+		So(err, ShouldBeError)
+		So(err.Error(), ShouldEqual, "return type cannot be set along with emit")
+	})
+}
@@ -0,0 +1,151 @@
+package eek
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+const (
+	// structTag is the tag key of struct fields, e.g. `eek:"Price,default=10"`. Name can be omitted, e.g. `eek:",default=10"`, and "-" skip the field
+	structTag = "eek"
+)
+
+// structField is reflect to a single struct field that is declared as variable
+type structField struct {
+	index        int
+	name         string
+	defaultValue interface{}
+}
+
+// DefineVariablesFromStruct define a variable for each exported field of the sample struct, typed after the field. The variable name and the default value are taken from the eek tag, e.g. `eek:"Price,default=10"`
+func (e *Eek) DefineVariablesFromStruct(sample interface{}) error {
+	t, err := structTypeOf(sample)
+	if err != nil {
+		return err
+	}
+
+	fields, err := structFields(t)
+	if err != nil {
+		return err
+	}
+
+	for _, each := range fields {
+		typ, imports, err := typeExpr(t.Field(each.index).Type)
+		if err != nil {
+			return fmt.Errorf("cannot declare variable %s from field %s. %s", each.name, t.Field(each.index).Name, err.Error())
+		}
+
+		for _, path := range imports {
+			if !e.isPackageImported(path) {
+				e.ImportPackage(path)
+			}
+		}
+		e.DefineVariable(Var{Name: each.name, Type: typ, DefaultValue: each.defaultValue})
+	}
+
+	return nil
+}
+
+// EvaluateStruct execute using field values of the input struct, fields are bound to variables the same way as DefineVariablesFromStruct declares them. Every field is bound, including the zero value ones
+func (e *Eek) EvaluateStruct(input interface{}) (interface{}, error) {
+	t, err := structTypeOf(input)
+	if err != nil {
+		return nil, err
+	}
+
+	fields, err := structFields(t)
+	if err != nil {
+		return nil, err
+	}
+
+	value := reflect.Indirect(reflect.ValueOf(input))
+	data := make(ExecVar)
+	for _, each := range fields {
+		data[each.name] = value.Field(each.index).Interface()
+	}
+
+	return e.Evaluate(data)
+}
+
+// structTypeOf returns type of struct or pointer to struct
+func structTypeOf(value interface{}) (reflect.Type, error) {
+	t := reflect.TypeOf(value)
+	if t != nil && t.Kind() == reflect.Ptr {
+		if reflect.ValueOf(value).IsNil() {
+			return nil, fmt.Errorf("struct must not be nil")
+		}
+		t = t.Elem()
+	}
+
+	if t == nil || t.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("%T is not a struct", value)
+	}
+
+	return t, nil
+}
+
+// structFields returns exported fields of the struct that are not skipped by the tag
+func structFields(t reflect.Type) ([]structField, error) {
+	fields := make([]structField, 0)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+
+		tag := field.Tag.Get(structTag)
+		if tag == "-" {
+			continue
+		}
+
+		each := structField{index: i, name: field.Name}
+		parts := strings.SplitN(tag, ",", 2)
+		if parts[0] != "" {
+			each.name = parts[0]
+		}
+
+		if len(parts) == 2 {
+			if !strings.HasPrefix(parts[1], "default=") {
+				return nil, fmt.Errorf("field %s has unknown tag option %s", field.Name, parts[1])
+			}
+
+			defaultValue, err := parseDefaultValue(strings.TrimPrefix(parts[1], "default="), field.Type)
+			if err != nil {
+				return nil, fmt.Errorf("field %s has invalid default value. %s", field.Name, err.Error())
+			}
+			each.defaultValue = defaultValue
+		}
+
+		fields = append(fields, each)
+	}
+
+	return fields, nil
+}
+
+// parseDefaultValue convert default value of the tag into a basic value that is printable as go source, e.g. "1m" into int64 for time.Duration
+func parseDefaultValue(str string, t reflect.Type) (interface{}, error) {
+	switch {
+	case isNumberKind(t.Kind()), t.Kind() == reflect.Bool, t.Kind() == reflect.String:
+	default:
+		return nil, fmt.Errorf("default value is not supported for type %s", t)
+	}
+
+	value, err := coerceString(reflect.ValueOf(str), t, false)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case isIntKind(t.Kind()):
+		return value.Int(), nil
+	case isUintKind(t.Kind()):
+		return value.Uint(), nil
+	case isFloatKind(t.Kind()):
+		return value.Float(), nil
+	case t.Kind() == reflect.Bool:
+		return value.Bool(), nil
+	}
+
+	return value.String(), nil
+}
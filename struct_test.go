@@ -0,0 +1,69 @@
+package eek
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type orderInput struct {
+	Price    float64 `eek:",default=10"`
+	Qty      int     `eek:"Quantity,default=1"`
+	Interval time.Duration
+	Note     string `eek:"-"`
+	internal int
+}
+
+func TestStruct(t *testing.T) {
+	Convey("Create Eek object with variables from struct", t, func() {
+		obj := New("evaluation_with_struct")
+		err := obj.DefineVariablesFromStruct(orderInput{})
+		So(err, ShouldBeNil)
+		So(obj.Variables(), ShouldResemble, []Var{
+			{Name: "Price", Type: "float64", DefaultValue: 10.0},
+			{Name: "Quantity", Type: "int", DefaultValue: int64(1)},
+			{Name: "Interval", Type: "time.Duration"},
+		})
+		So(obj.isPackageImported("time"), ShouldBeTrue)
+
+		obj.PrepareEvaluation(`return Price * float64(Quantity) * Interval.Minutes()`)
+
+		Convey("Build operation", func() {
+			err := obj.Build()
+			So(err, ShouldBeNil)
+
+			Convey("Test exec with struct", func() {
+				output, err := obj.EvaluateStruct(orderInput{Price: 2.5, Qty: 4, Interval: 2 * time.Minute})
+				So(err, ShouldBeNil)
+				So(output, ShouldEqual, 20)
+
+				output, err = obj.EvaluateStruct(&orderInput{Price: 1, Qty: 1, Interval: time.Minute})
+				So(err, ShouldBeNil)
+				So(output, ShouldEqual, 1)
+			})
+
+			Convey("Test exec with non struct", func() {
+				_, err := obj.EvaluateStruct(ExecVar{"Price": 1})
+				So(err, ShouldBeError)
+				So(err.Error(), ShouldEqual, "eek.ExecVar is not a struct")
+			})
+		})
+	})
+
+	Convey("Invalid tag", t, func() {
+		obj := New("evaluation_with_struct")
+
+		err := obj.DefineVariablesFromStruct(struct {
+			Price float64 `eek:",default=abc"`
+		}{})
+		So(err, ShouldBeError)
+		So(err.Error(), ShouldEqual, `field Price has invalid default value. strconv.ParseFloat: parsing "abc": invalid syntax`)
+
+		err = obj.DefineVariablesFromStruct(struct {
+			Price float64 `eek:",required"`
+		}{})
+		So(err, ShouldBeError)
+		So(err.Error(), ShouldEqual, "field Price has unknown tag option required")
+	})
+}
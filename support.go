@@ -0,0 +1,217 @@
+package eek
+
+import (
+	"fmt"
+	"math/rand"
+	"plugin"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// supportNames is list of identifiers declared by the support code, the formula cannot refer to them
+var supportNames = map[string]bool{
+	"EekNow": true, "EekRand": true, "eekRandomInt": true, "EekFetch": true,
+	"EekEmit": true, "EekPresence": true, "EekReset": true,
+}
+
+// isSupportName returns true when name is reserved for the support code
+func isSupportName(name string) bool {
+	return supportNames[name] || strings.HasPrefix(name, lazyGetterName(""))
+}
+
+// supportImports returns imports needed by the support file. Imports for eek internal use have explicit name to avoid conflict with the packages of host function signatures
+func (e *Eek) supportImports() []string {
+	imports := make([]string, 0)
+
+	for _, each := range e.hostFuncImports() {
+		imports = append(imports, strconv.Quote(each))
+	}
+
+	if e.Deterministic {
+		imports = append(imports, `eektime "time"`, `eekrand "math/rand"`)
+	}
+
+	return imports
+}
+
+// supportFile returns the code of the support file, that is built along with main.go. Imports are file scoped, so the formula cannot use the packages imported for eek internal use. Empty string is returned when there is nothing to support
+func (e *Eek) supportFile() string {
+	layout := ""
+
+	if e.Deterministic {
+		layout = fmt.Sprintf("%s\n%s", layout, strings.TrimSpace(`
+			var EekNow = eektime.Now
+			var EekRand = eekrand.New(eekrand.NewSource(0))
+
+			func eekRandomInt(min, max int) int {
+				return EekRand.Intn(max-min) + min
+			}
+		`))
+	}
+
+	for _, each := range e.hostFunctions {
+		if signature, _, err := each.signature(); err == nil {
+			layout = fmt.Sprintf("%s\nvar %s %s", layout, each.name, signature)
+		}
+	}
+
+	if layout == "" {
+		return ""
+	}
+
+	imports := ""
+	if supportImports := e.supportImports(); len(supportImports) > 0 {
+		imports = fmt.Sprintf("import (\n%s\n)\n\n", strings.Join(supportImports, "\n"))
+	}
+
+	return fmt.Sprintf("package main\n\n%s%s\n", imports, strings.TrimSpace(layout))
+}
+
+// supportLayout returns the support code placed in main.go, a generated code that connects the formula to the host
+func (e *Eek) supportLayout() string {
+	layout := ""
+
+	if e.Deterministic {
+		// shadowed packages might be no longer used after the rewrite
+		shadowed := map[string]string{"time": "Now", "math/rand": "Intn", gubrakPackage: "RandomInt"}
+		for _, each := range e.packages {
+			if function, ok := shadowed[each]; ok {
+				layout = fmt.Sprintf("%s\nvar _ = %s.%s", layout, packageName(each), function)
+			}
+		}
+	}
+
+	if lazyVariables := e.lazyVariables(); len(lazyVariables) > 0 {
+		layout = fmt.Sprintf("%s\nvar EekFetch func(name string)", layout)
+		for _, each := range lazyVariables {
+			layout = fmt.Sprintf("%s\nfunc %s() %s {\n\tEekFetch(%q)\n\treturn %s\n}", layout, lazyGetterName(each.Name), each.Type, each.Name, each.Name)
+		}
+	}
+
+	if emitLayout := e.emitLayout(); emitLayout != "" {
+		layout = fmt.Sprintf("%s\n%s", layout, emitLayout)
+	}
+
+	if optionalLayout := e.optionalLayout(); optionalLayout != "" {
+		layout = fmt.Sprintf("%s\n%s", layout, optionalLayout)
+	}
+
+	if resetLayout := e.resetLayout(); resetLayout != "" {
+		layout = fmt.Sprintf("%s\n%s", layout, resetLayout)
+	}
+
+	return strings.TrimSpace(layout)
+}
+
+// resetLayout returns the generated code of EekReset, that set every variable back to its default value, or zero value when there is no default
+func (e *Eek) resetLayout() string {
+	resets := make([]string, 0)
+	for _, each := range e.variables {
+		if each.Name == "" || each.Type == "" {
+			continue
+		}
+
+		if each.DefaultValue == nil {
+			resets = append(resets, fmt.Sprintf("\t%s = *new(%s)", each.Name, each.Type))
+		} else {
+			resets = append(resets, fmt.Sprintf("\t%s = %s", each.Name, formatValue(each.DefaultValue)))
+		}
+	}
+
+	if len(resets) == 0 {
+		return ""
+	}
+
+	return fmt.Sprintf("func EekReset() {\n%s\n}", strings.Join(resets, "\n"))
+}
+
+// resetVariables set every variable back to its default value before the data is bound. The plugin is shared by every Eek object of the very same code, so values of previous evaluation, possibly of other Eek object, must not be visible
+func (e *Eek) resetVariables(p *plugin.Plugin) error {
+	if e.resetLayout() == "" {
+		return nil
+	}
+
+	lookedUpReset, err := p.Lookup("EekReset")
+	if err != nil {
+		return err
+	}
+
+	reset, ok := lookedUpReset.(func())
+	if !ok {
+		return fmt.Errorf("EekReset has unexpected type %T. please try to rebuild the formula", lookedUpReset)
+	}
+
+	reset()
+	return nil
+}
+
+// supportState is reflect to the support functions bound for a single evaluation
+type supportState struct {
+	fetcher *fetcher
+	emitter *emitter
+}
+
+// failure returns whether the formula is stopped by the support functions, along with the recorded error. The formula is able to recover their panic, but not the recorded error
+func (s *supportState) failure() (bool, error) {
+	if s.fetcher != nil {
+		if err := s.fetcher.failure(); err != nil {
+			return true, err
+		}
+	}
+	if s.emitter != nil {
+		return s.emitter.failure()
+	}
+
+	return false, nil
+}
+
+// bindSupport set the support variables of the plugin for a single evaluation
+func (e *Eek) bindSupport(p *plugin.Plugin, data ExecVar, options EvalOptions) (*supportState, error) {
+	state := new(supportState)
+
+	if e.Deterministic {
+		now := options.Now
+		if err := setSupportVariable(p, "EekNow", func() time.Time { return now }); err != nil {
+			return nil, err
+		}
+		if err := setSupportVariable(p, "EekRand", rand.New(rand.NewSource(options.Seed))); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(e.lazyVariables()) > 0 {
+		state.fetcher = e.newFetcher(p, data)
+		if err := setSupportVariable(p, "EekFetch", state.fetcher.fetch); err != nil {
+			return nil, err
+		}
+	}
+
+	if e.UseEmit && options.Emit != nil {
+		state.emitter = &emitter{fn: options.Emit}
+		if err := setSupportVariable(p, "EekEmit", state.emitter.emit); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := e.bindPresence(p, data); err != nil {
+		return nil, err
+	}
+
+	if err := e.bindHostFunctions(p); err != nil {
+		return nil, err
+	}
+
+	return state, nil
+}
+
+func setSupportVariable(p *plugin.Plugin, name string, value interface{}) error {
+	lookedUpVar, err := p.Lookup(name)
+	if err != nil {
+		return err
+	}
+
+	reflect.ValueOf(lookedUpVar).Elem().Set(reflect.ValueOf(value))
+	return nil
+}
@@ -0,0 +1,144 @@
+package eek
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// typeExpr returns the go source representation of t, along with the import paths needed to use it inside the generated code
+func typeExpr(t reflect.Type) (string, []string, error) {
+	imports := make([]string, 0)
+	expr, err := writeTypeExpr(t, &imports)
+	if err != nil {
+		return "", nil, err
+	}
+
+	return expr, imports, nil
+}
+
+func writeTypeExpr(t reflect.Type, imports *[]string) (string, error) {
+	if t.Name() != "" {
+		if t.PkgPath() == "" {
+			return t.Name(), nil
+		}
+
+		if t.PkgPath() == "main" || !isExportedName(t.Name()) {
+			return "", fmt.Errorf("type %s is not accessible from the evaluation", t.String())
+		}
+
+		*imports = appendUnique(*imports, t.PkgPath())
+		return t.String(), nil
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		elem, err := writeTypeExpr(t.Elem(), imports)
+		return "*" + elem, err
+	case reflect.Slice:
+		elem, err := writeTypeExpr(t.Elem(), imports)
+		return "[]" + elem, err
+	case reflect.Array:
+		elem, err := writeTypeExpr(t.Elem(), imports)
+		return fmt.Sprintf("[%d]%s", t.Len(), elem), err
+	case reflect.Chan:
+		elem, err := writeTypeExpr(t.Elem(), imports)
+		switch t.ChanDir() {
+		case reflect.RecvDir:
+			return "<-chan " + elem, err
+		case reflect.SendDir:
+			return "chan<- " + elem, err
+		}
+		return "chan " + elem, err
+	case reflect.Map:
+		key, err := writeTypeExpr(t.Key(), imports)
+		if err != nil {
+			return "", err
+		}
+		elem, err := writeTypeExpr(t.Elem(), imports)
+		return fmt.Sprintf("map[%s]%s", key, elem), err
+	case reflect.Func:
+		params := make([]string, 0)
+		for i := 0; i < t.NumIn(); i++ {
+			param, err := writeTypeExpr(t.In(i), imports)
+			if err != nil {
+				return "", err
+			}
+			if t.IsVariadic() && i == t.NumIn()-1 {
+				param = "..." + strings.TrimPrefix(param, "[]")
+			}
+			params = append(params, param)
+		}
+
+		results := make([]string, 0)
+		for i := 0; i < t.NumOut(); i++ {
+			result, err := writeTypeExpr(t.Out(i), imports)
+			if err != nil {
+				return "", err
+			}
+			results = append(results, result)
+		}
+
+		expr := fmt.Sprintf("func(%s)", strings.Join(params, ", "))
+		switch len(results) {
+		case 0:
+			return expr, nil
+		case 1:
+			return fmt.Sprintf("%s %s", expr, results[0]), nil
+		}
+		return fmt.Sprintf("%s (%s)", expr, strings.Join(results, ", ")), nil
+	case reflect.Struct:
+		fields := make([]string, 0)
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			if field.PkgPath != "" {
+				return "", fmt.Errorf("type %s has unexported field %s", t.String(), field.Name)
+			}
+
+			fieldType, err := writeTypeExpr(field.Type, imports)
+			if err != nil {
+				return "", err
+			}
+
+			each := fmt.Sprintf("%s %s", field.Name, fieldType)
+			if field.Anonymous {
+				each = fieldType
+			}
+			if field.Tag != "" {
+				each = fmt.Sprintf("%s `%s`", each, field.Tag)
+			}
+			fields = append(fields, each)
+		}
+		return fmt.Sprintf("struct{ %s }", strings.Join(fields, "; ")), nil
+	case reflect.Interface:
+		if t.NumMethod() == 0 {
+			return "interface{}", nil
+		}
+	}
+
+	return "", fmt.Errorf("type %s is not supported", t.String())
+}
+
+func isExportedName(name string) bool {
+	r, _ := utf8.DecodeRuneInString(name)
+	return unicode.IsUpper(r)
+}
+
+func appendUnique(list []string, values ...string) []string {
+	for _, value := range values {
+		found := false
+		for _, each := range list {
+			if each == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, value)
+		}
+	}
+
+	return list
+}
@@ -0,0 +1,210 @@
+package eek
+
+import (
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"strings"
+)
+
+// ValidationError is returned on build when the definitions are invalid. It holds every problem found, not only the first one
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return strings.Join(e.Problems, "; ")
+}
+
+// validate check every variable, function, type, constant, and entry definition
+func (e *Eek) validate() error {
+	problems := make([]string, 0)
+	report := func(format string, args ...interface{}) {
+		problems = append(problems, fmt.Sprintf(format, args...))
+	}
+
+	// declared keeps kind of each declared name, to detect duplicates and collisions
+	declared := make(map[string]string)
+	declare := func(kind, name string) {
+		if existing, ok := declared[name]; ok {
+			report("%s %s collides with %s %s", kind, name, existing, name)
+			return
+		}
+		declared[name] = kind
+	}
+	checkName := func(kind, name string, mustBeExported bool) bool {
+		if name == "" {
+			report("defined %s must have a name", kind)
+			return false
+		} else if !token.IsIdentifier(name) {
+			report("defined %s name %s is not a valid identifier", kind, name)
+			return false
+		} else if isSupportName(name) {
+			report("defined %s name %s is reserved", kind, name)
+			return false
+		} else if mustBeExported && !isExportedName(name) {
+			report("defined %s must be exported. %s must be %s%s", kind, name, strings.ToUpper(name[:1]), name[1:])
+			return false
+		}
+
+		declare(kind, name)
+		return true
+	}
+
+	// the support code is generated along with the formula, referring to it would bypass the enabled features
+	checkReferences := func(s *source) {
+		for _, ident := range s.file.Unresolved {
+			if isSupportName(ident.Name) {
+				report("%s: %s is reserved for eek internal use", s.fset.Position(ident.Pos()), ident.Name)
+			}
+		}
+	}
+
+	for _, each := range e.packages {
+		if each != "" {
+			declared[packageName(each)] = "package"
+		}
+	}
+
+	for _, each := range e.types {
+		if checkName("type", each.Name, true) && !isTypeExpr(each.Definition) {
+			report("defined type %s has invalid definition %q", each.Name, each.Definition)
+		}
+	}
+
+	for _, each := range e.constants {
+		if !checkName("constant", each.Name, true) {
+			continue
+		}
+
+		if each.Type != "" && !isTypeExpr(each.Type) {
+			report("defined constant %s has invalid type %q", each.Name, each.Type)
+		}
+		if each.Value == nil {
+			report("defined constant %s must have a value", each.Name)
+		}
+	}
+
+	for _, each := range e.functions {
+		if !checkName("function", each.Name, false) {
+			continue
+		}
+
+		if s, err := parseFunction(each.Name, strings.TrimSpace(each.BodyFunction)); err != nil {
+			report("defined function %s has invalid body. %s", each.Name, err.Error())
+		} else {
+			checkReferences(s)
+		}
+	}
+
+	for _, each := range e.variables {
+		if !checkName("variable", each.Name, true) {
+			continue
+		}
+
+		if strings.TrimSpace(each.Type) == "" {
+			report("defined variable %s must have a type", each.Name)
+		} else if !isTypeExpr(each.Type) {
+			report("defined variable %s has invalid type %q", each.Name, each.Type)
+		}
+
+		if each.Constraint != nil {
+			problems = append(problems, each.Constraint.validate(each.Name)...)
+		}
+	}
+
+	for _, each := range e.outputs {
+		// err is the name of the error result when UseErrorReturn is enabled
+		if each.Name == "err" {
+			report("defined output name %s is reserved", each.Name)
+			continue
+		} else if !checkName("output", each.Name, false) {
+			continue
+		}
+
+		if strings.TrimSpace(each.Type) == "" {
+			report("defined output %s must have a type", each.Name)
+		} else if !isTypeExpr(each.Type) {
+			report("defined output %s has invalid type %q", each.Name, each.Type)
+		}
+		if each.Constraint != nil || each.Source != nil || each.Optional {
+			report("defined output %s supports only name, type, and default value", each.Name)
+		}
+	}
+	if len(e.outputs) > 0 && e.returnType != "" {
+		report("return type cannot be set along with outputs")
+	}
+
+	for _, each := range e.hostFunctions {
+		if !checkName("host function", each.name, true) {
+			continue
+		}
+
+		if _, _, err := each.signature(); err != nil {
+			report("%s", err.Error())
+		}
+	}
+
+	if len(e.optionalVariables()) > 0 {
+		declare("support function", isSetFunction)
+	}
+	if e.UseEmit {
+		declare("support function", emitFunction)
+		if len(e.outputs) > 0 {
+			report("outputs cannot be defined along with emit")
+		} else if e.returnType != "" {
+			report("return type cannot be set along with emit")
+		}
+	}
+
+	for _, each := range e.entries {
+		declare("entry", each.name)
+
+		if s, err := parseFormula(each.name, each.formula); err != nil {
+			report("evaluation formula %s is invalid. %s", each.name, err.Error())
+		} else {
+			checkReferences(s)
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+
+	return nil
+}
+
+// isTypeExpr returns true when str is parsed as go type expression
+func isTypeExpr(str string) bool {
+	expr, err := parser.ParseExpr(str)
+	if err != nil {
+		return false
+	}
+
+	return isTypeNode(expr)
+}
+
+func isTypeNode(expr ast.Expr) bool {
+	switch n := expr.(type) {
+	case *ast.Ident:
+		return true
+	case *ast.SelectorExpr:
+		_, ok := n.X.(*ast.Ident)
+		return ok
+	case *ast.ParenExpr:
+		return isTypeNode(n.X)
+	case *ast.StarExpr:
+		return isTypeNode(n.X)
+	case *ast.ArrayType:
+		return isTypeNode(n.Elt)
+	case *ast.MapType:
+		return isTypeNode(n.Key) && isTypeNode(n.Value)
+	case *ast.ChanType:
+		return isTypeNode(n.Value)
+	case *ast.FuncType, *ast.StructType, *ast.InterfaceType:
+		return true
+	}
+
+	return false
+}
@@ -0,0 +1,57 @@
+package eek
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestValidation(t *testing.T) {
+	Convey("Error definitions are invalid", t, func() {
+		obj := New("validation")
+		obj.ImportPackage("strings")
+		obj.DefineType("Tier", "int +")
+		obj.DefineConstant("Gold", "Tier", nil)
+		obj.DefineFunction(Func{Name: "strings", BodyFunction: `func() {}`})
+		obj.DefineFunction(Func{Name: "func", BodyFunction: `func() {}`})
+		obj.DefineFunction(Func{Name: "Broken", BodyFunction: `func( {}`})
+		obj.DefineVariable(Var{Name: "", Type: "int"})
+		obj.DefineVariable(Var{Name: "amount", Type: "int"})
+		obj.DefineVariable(Var{Name: "A", Type: ""})
+		obj.DefineVariable(Var{Name: "B", Type: "1 + 2"})
+		obj.DefineVariable(Var{Name: "C", Type: "map[string][]int"})
+		obj.DefineVariable(Var{Name: "C", Type: "int"})
+		obj.DefineVariable(Var{Name: "Broken", Type: "int"})
+		obj.PrepareEvaluation(`return C`)
+		obj.PrepareEvaluation(`return 1`, "Tier")
+
+		err := obj.Build()
+		So(err, ShouldBeError)
+
+		validationErr, ok := err.(*ValidationError)
+		So(ok, ShouldBeTrue)
+		So(validationErr.Problems, ShouldResemble, []string{
+			`defined type Tier has invalid definition "int +"`,
+			`defined constant Gold must have a value`,
+			`function strings collides with package strings`,
+			`defined function name func is not a valid identifier`,
+			`defined function Broken has invalid body. Broken:1:7: expected ')', found '{'`,
+			`defined variable must have a name`,
+			`defined variable must be exported. amount must be Amount`,
+			`defined variable A must have a type`,
+			`defined variable B has invalid type "1 + 2"`,
+			`variable C collides with variable C`,
+			`variable Broken collides with function Broken`,
+			`entry Tier collides with type Tier`,
+		})
+	})
+
+	Convey("Type expressions", t, func() {
+		So(isTypeExpr("*time.Time"), ShouldBeTrue)
+		So(isTypeExpr("[]struct{ A int }"), ShouldBeTrue)
+		So(isTypeExpr("func(int) (string, error)"), ShouldBeTrue)
+		So(isTypeExpr("chan<- interface{}"), ShouldBeTrue)
+		So(isTypeExpr("a.b.c"), ShouldBeFalse)
+		So(isTypeExpr("f()"), ShouldBeFalse)
+	})
+}